@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/go-serve/pkg/ctxLogger"
+	"github.com/Seann-Moser/interfacery/pkg/parser"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/spf13/cobra"
+)
+
+// cliCmd generates a cobra command tree for interfaces
+var cliCmd = &cobra.Command{
+	Use:   "cli",
+	Short: "Generate a cobra command tree with a subcommand per interface method",
+	Long: `Writes a New<Interface>Command function for every selected interface, returning
+a command with a subcommand per method. Params become flags: strings and ints
+as they are and other types, such as structs, as JSON given inline, from a file
+with @path or from stdin with -. Results are printed as JSON or, with
+--output table, as tables.
+
+The commands call the implementation passed to New<Interface>Command. When
+--client-package names the package of the generated HTTP client, the commands
+also get a --url flag calling the handlers over HTTP instead:
+
+  interfacery cli --src-dir ./pkg/users --dest-dir ./internal/admin --client-package example.com/app/pkg/client`,
+	Args: cobra.NoArgs,
+	RunE: CLIRunner,
+}
+
+func init() {
+	cliCmd.Flags().AddFlagSet(CLIFlags())
+	rootCmd.AddCommand(cliCmd)
+}
+
+func CLIFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("cli", pflag.ExitOnError)
+	fs.String("src-dir", "./", "")
+	fs.String("dest-dir", "./pkg/cli", "")
+	fs.String("template", "", "template file, or directory of templates, overriding the built-in templates")
+	fs.String("client-package", "", "import path of the generated HTTP client, adds a --url flag calling it")
+	fs.AddFlagSet(SelectionFlags())
+	fs.AddFlagSet(OutputFlags())
+	return fs
+}
+
+func CLIRunner(cmd *cobra.Command, args []string) error {
+	sel, err := selectionFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	gofiles, err := parser.FindInterfaces(viper.GetString("src-dir"), sel, viper.GetString("dest-dir"))
+	if err != nil {
+		return err
+	}
+	opts := parser.RenderOptions{Kind: "cli", OutputDir: viper.GetString("dest-dir"), Options: map[string]string{}}
+	if clientPackage := viper.GetString("client-package"); clientPackage != "" {
+		opts.Options["client_package"] = clientPackage
+	}
+	if templatePath := viper.GetString("template"); templatePath != "" {
+		opts.Template, opts.Partials, err = parser.LoadTemplateOverride(templatePath, opts.Kind)
+		if err != nil {
+			return err
+		}
+	}
+	for _, gofile := range gofiles {
+		ctxLogger.Info(cmd.Context(), "Generating CLI for "+gofile.FilePath, zap.Strings("interfaces", gofile.Interfaces))
+	}
+	files, err := parser.RenderFiles(cmd.Context(), gofiles, opts)
+	if err != nil {
+		return err
+	}
+	return writeOrCheck(cmd, files)
+}
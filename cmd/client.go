@@ -4,12 +4,13 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/Seann-Moser/go-serve/pkg/ctxLogger"
 	"github.com/Seann-Moser/interfacery/pkg/parser"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
-	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -36,24 +37,104 @@ func Flags() *pflag.FlagSet {
 	fs := pflag.NewFlagSet("client", pflag.ExitOnError)
 	fs.String("src-dir", "./", "")
 	fs.String("dest-dir", "./pkg/client", "")
-	fs.String("interface", "", "")
+	fs.String("template", "", "template file, or directory of templates, overriding the built-in templates")
+	fs.AddFlagSet(SelectionFlags())
+	fs.AddFlagSet(OutputFlags())
+	return fs
+}
+
+// SelectionFlags select the interfaces that are generated, see parser.Selection.
+func SelectionFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("selection", pflag.ExitOnError)
+	fs.StringArray("interface", nil, "interfaces to generate: a name, glob, /regexp/ or package qualified pattern such as ./pkg/users.UserService")
+	fs.StringArray("exclude", nil, "interfaces to skip, using the same patterns as --interface")
+	fs.Bool("marked", false, "only generate interfaces marked with a "+parser.MarkerComment+" comment")
+	fs.Bool("include-unexported", false, "also generate unexported interfaces")
+	fs.Bool("include-tests", false, "also scan _test.go files")
+	fs.StringSlice("tags", nil, "comma separated build tags considered satisfied when reading packages")
+	return fs
+}
+
+// selectionFromFlags reads the SelectionFlags of cmd. The patterns are read from the flags
+// as given, viper would split them on the commas of patterns such as /^Get.{1,3}$/.
+func selectionFromFlags(cmd *cobra.Command) (parser.Selection, error) {
+	include, err := cmd.Flags().GetStringArray("interface")
+	if err != nil {
+		return parser.Selection{}, err
+	}
+	exclude, err := cmd.Flags().GetStringArray("exclude")
+	if err != nil {
+		return parser.Selection{}, err
+	}
+	return parser.Selection{
+		Include:    include,
+		Exclude:    exclude,
+		Marked:     viper.GetBool("marked"),
+		Unexported: viper.GetBool("include-unexported"),
+		Tests:      viper.GetBool("include-tests"),
+		BuildTags:  viper.GetStringSlice("tags"),
+	}, nil
+}
+
+// OutputFlags controls whether generated files are written or compared with the files on disk.
+func OutputFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("output", pflag.ExitOnError)
+	fs.Bool("check", false, "exit with an error instead of writing when generated files are stale")
+	fs.Bool("diff", false, "print a unified diff of the changes instead of writing them")
 	return fs
 }
 
 func ClientRunner(cmd *cobra.Command, args []string) error {
-	gofiles, err := parser.FindGoFilesWithInterfaces(viper.GetString("src-dir"), viper.GetString("interface"), strings.TrimPrefix(viper.GetString("dest-dir"), "./"))
+	sel, err := selectionFromFlags(cmd)
 	if err != nil {
 		return err
 	}
+	gofiles, err := parser.FindInterfaces(viper.GetString("src-dir"), sel, viper.GetString("dest-dir"))
+	if err != nil {
+		return err
+	}
+	opts := parser.RenderOptions{Kind: "handler", OutputDir: viper.GetString("dest-dir")}
+	if templatePath := viper.GetString("template"); templatePath != "" {
+		opts.Template, opts.Partials, err = parser.LoadTemplateOverride(templatePath, opts.Kind)
+		if err != nil {
+			return err
+		}
+	}
 	for _, gofile := range gofiles {
 		ctxLogger.Info(cmd.Context(), "Generating client for "+gofile.FilePath+"", zap.Strings("interfaces", gofile.Interfaces))
+	}
+	files, err := parser.RenderFiles(cmd.Context(), gofiles, opts)
+	if err != nil {
+		return err
+	}
+	return writeOrCheck(cmd, files)
+}
 
-		err = parser.GenerateHTTPHandlers(cmd.Context(), gofile, gofile.PackageName, viper.GetString("dest-dir"), "")
-		if err != nil {
-			return err
+// writeOrCheck writes the generated files, or when --check or --diff is set,
+// compares them with the files on disk without writing anything.
+func writeOrCheck(cmd *cobra.Command, files []parser.GeneratedFile) error {
+	check, diff := viper.GetBool("check"), viper.GetBool("diff")
+	if !check && !diff {
+		return parser.WriteGeneratedFiles(files)
+	}
 
+	diffs, err := parser.DiffGeneratedFiles(files)
+	if err != nil {
+		return err
+	}
+	for _, d := range diffs {
+		if diff {
+			_, _ = fmt.Fprint(cmd.OutOrStdout(), d.Diff)
+			continue
 		}
-
+		if d.Missing {
+			ctxLogger.Warn(cmd.Context(), "Generated file is missing", zap.String("path", d.Path))
+		} else {
+			ctxLogger.Warn(cmd.Context(), "Generated file is stale", zap.String("path", d.Path))
+		}
+	}
+	if check && len(diffs) > 0 {
+		return fmt.Errorf("%d generated file(s) are out of date, rerun interfacery to regenerate them", len(diffs))
 	}
 	return nil
 }
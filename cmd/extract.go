@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/go-serve/pkg/ctxLogger"
+	"github.com/Seann-Moser/interfacery/pkg/parser"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/spf13/cobra"
+)
+
+// extractCmd generates an interface from a concrete type
+var extractCmd = &cobra.Command{
+	Use:   "extract",
+	Short: "Generate an interface declaring the exported methods of a concrete type",
+	Long: `Writes an interface with every exported method of a struct or other defined
+type, in declaration order and with the doc comments of the methods, followed
+by an assertion that the type implements it:
+
+  interfacery extract --type ./pkg/store.Store --name StoreService --exclude Close
+
+The interface is written next to the type by default, so the other commands can
+generate handlers, clients and mocks from it. Rerun extract when the type's
+methods change, or use --check to fail when the interface is out of date.`,
+	Args: cobra.NoArgs,
+	RunE: ExtractRunner,
+}
+
+func init() {
+	extractCmd.Flags().AddFlagSet(ExtractFlags())
+	rootCmd.AddCommand(extractCmd)
+}
+
+func ExtractFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("extract", pflag.ExitOnError)
+	fs.String("type", "", "package qualified type to extract, such as ./pkg/store.Store")
+	fs.String("name", "", "name of the interface, <Type>Interface by default")
+	fs.StringArray("method", nil, "methods to include: a name, glob or /regexp/, every exported method by default")
+	fs.StringArray("exclude", nil, "methods to leave out, using the same patterns as --method")
+	fs.String("output", "", "file to write the interface to, <name>.go next to the type by default")
+	fs.StringSlice("tags", nil, "comma separated build tags considered satisfied when reading packages")
+	fs.AddFlagSet(OutputFlags())
+	return fs
+}
+
+func ExtractRunner(cmd *cobra.Command, args []string) error {
+	// Patterns are read as given, viper would split them on commas
+	include, err := cmd.Flags().GetStringArray("method")
+	if err != nil {
+		return err
+	}
+	exclude, err := cmd.Flags().GetStringArray("exclude")
+	if err != nil {
+		return err
+	}
+	opts := parser.ExtractOptions{
+		Type:      viper.GetString("type"),
+		Name:      viper.GetString("name"),
+		Include:   include,
+		Exclude:   exclude,
+		Output:    viper.GetString("output"),
+		BuildTags: viper.GetStringSlice("tags"),
+	}
+	file, err := parser.ExtractInterface(cmd.Context(), opts)
+	if err != nil {
+		return err
+	}
+	ctxLogger.Info(cmd.Context(), "Extracted interface", zap.String("type", opts.Type), zap.String("interface", file.Interface), zap.String("path", file.Path))
+	return writeOrCheck(cmd, []parser.GeneratedFile{file})
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/go-serve/pkg/ctxLogger"
+	"github.com/Seann-Moser/interfacery/pkg/parser"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/spf13/cobra"
+)
+
+// generateCmd runs every target in the project config
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Run every generation target in the project config",
+	Long: `Reads the project config (interfacery.yaml by default) and renders every
+target it lists. A target selects interfaces from its source directories and
+renders one generator kind into its output directory, for example:
+
+targets:
+  - name: user-handlers
+    sources: [./pkg/users]
+    interfaces: [UserService]
+    kind: handler
+    output: ./pkg/handlers
+    route_prefix: /api
+  - name: user-client
+    sources: [./pkg/users]
+    kind: client
+    output: ./pkg/client
+    package: userclient
+
+The built-in kinds, see "interfacery help kinds" for their options:
+
+  handler            HTTP handlers calling the implementation
+  client             HTTP client satisfying the interface
+  proto              .proto file with a service per interface
+  grpc               gRPC server and client
+  connect            Connect protocol server
+  connect-client     Connect protocol client
+  jsonrpc            JSON-RPC 2.0 server over HTTP and streams
+  jsonrpc-client     JSON-RPC 2.0 client over HTTP and streams
+  mcp                Model Context Protocol server exposing methods as tools
+  cli                cobra command with a subcommand per method
+  python             Python client calling the handler routes
+  graphql            GraphQL schema
+  graphql-resolvers  GraphQL resolvers calling the implementation
+  fake               in-memory implementation for tests
+  recorder           recorder and replayer of calls
+  resilience         retry and circuit breaker wrapper
+
+A kind that is not built in runs the interfacery-gen-<kind> executable found on
+the PATH. It receives the model of the target's interfaces as JSON on stdin
+(see the inspect command) along with the target's options, and answers with
+the files to write on stdout.`,
+	RunE: GenerateRunner,
+}
+
+func init() {
+	generateCmd.Flags().AddFlagSet(GenerateFlags())
+	rootCmd.AddCommand(generateCmd)
+}
+
+func GenerateFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("generate", pflag.ExitOnError)
+	fs.String("config", parser.DefaultConfigFile, "project config listing the generation targets")
+	fs.AddFlagSet(OutputFlags())
+	return fs
+}
+
+func GenerateRunner(cmd *cobra.Command, args []string) error {
+	config, err := parser.LoadConfig(viper.GetString("config"))
+	if err != nil {
+		return err
+	}
+	for _, target := range config.Targets {
+		ctxLogger.Info(cmd.Context(), "Generating target "+target.Name, zap.String("kind", target.Kind), zap.String("output", target.Output))
+	}
+	// --check and --diff compare the rendered files with the files on disk instead of writing them
+	check, diff := viper.GetBool("check"), viper.GetBool("diff")
+	result, err := parser.NewGenerator(parser.WithTargets(config.Targets...), parser.WithDryRun(check || diff)).Generate(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if check || diff {
+		files := make([]parser.GeneratedFile, 0, len(result.Files))
+		for _, f := range result.Files {
+			files = append(files, parser.GeneratedFile{Path: f.Path, Interface: f.Interface, Content: f.Content})
+		}
+		return writeOrCheck(cmd, files)
+	}
+	for _, f := range result.Changed() {
+		ctxLogger.Info(cmd.Context(), "Wrote "+f.Path, zap.String("target", f.Target), zap.String("status", string(f.Status)))
+	}
+	return nil
+}
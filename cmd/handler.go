@@ -5,8 +5,6 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/spf13/cobra"
 )
 
@@ -20,12 +18,11 @@ and usage of using your command. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("handler called")
-	},
+	RunE: ClientRunner,
 }
 
 func init() {
+	handlerCmd.Flags().AddFlagSet(Flags())
 	rootCmd.AddCommand(handlerCmd)
 
 	// Here you will define your flags and configuration settings.
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"github.com/Seann-Moser/go-serve/pkg/ctxLogger"
+	"github.com/Seann-Moser/interfacery/pkg/parser"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"github.com/spf13/cobra"
+)
+
+// implCmd generates stub implementations of interfaces
+var implCmd = &cobra.Command{
+	Use:   "impl",
+	Short: "Generate a stub type implementing the selected interfaces",
+	Long: `Writes a type implementing every selected interface, with each method
+returning zero values and a "not implemented" error, or panicking when the
+method can not return an error:
+
+  interfacery impl --src-dir ./pkg/users --interface UserService --dest-dir ./internal/users --type Service
+
+When the destination package already declares the type, only the methods it is
+missing are appended to the file declaring it. Methods that are already written
+are never changed, so impl can be rerun after methods are added to an interface.`,
+	Args: cobra.NoArgs,
+	RunE: ImplRunner,
+}
+
+func init() {
+	implCmd.Flags().AddFlagSet(ImplFlags())
+	rootCmd.AddCommand(implCmd)
+}
+
+func ImplFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("impl", pflag.ExitOnError)
+	fs.String("src-dir", "./", "")
+	fs.String("dest-dir", "./pkg/impl", "package directory of the implementations")
+	fs.String("type", "", "name of the implementing type, <Interface>Impl by default, only valid for a single interface")
+	fs.AddFlagSet(SelectionFlags())
+	fs.AddFlagSet(OutputFlags())
+	return fs
+}
+
+func ImplRunner(cmd *cobra.Command, args []string) error {
+	sel, err := selectionFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	gofiles, err := parser.FindInterfaces(viper.GetString("src-dir"), sel)
+	if err != nil {
+		return err
+	}
+	for _, gofile := range gofiles {
+		ctxLogger.Info(cmd.Context(), "Generating implementations for "+gofile.FilePath, zap.Strings("interfaces", gofile.Interfaces))
+	}
+	files, err := parser.ImplementInterfaces(cmd.Context(), gofiles, parser.ImplOptions{
+		OutputDir: viper.GetString("dest-dir"),
+		Type:      viper.GetString("type"),
+	})
+	if err != nil {
+		return err
+	}
+	return writeOrCheck(cmd, files)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/Seann-Moser/interfacery/pkg/parser"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/spf13/cobra"
+)
+
+// implementationsCmd lists the types implementing interfaces
+var implementationsCmd = &cobra.Command{
+	Use:     "implementations",
+	Aliases: []string{"impls"},
+	Short:   "List the types implementing the selected interfaces and write assertions for them",
+	Long: `Loads every package below --src-dir and lists the defined types implementing
+the selected interfaces, either directly or through a pointer.
+
+With --write, every package with implementations gets a file asserting them:
+
+  var (
+  	_ users.UserService = (*Service)(nil)
+  )
+
+A method added to an interface then fails to compile at the implementation,
+rather than only where the implementation is used. Assertion files are ignored
+while the packages are loaded, so rerun the command to update them.`,
+	Args: cobra.NoArgs,
+	RunE: ImplementationsRunner,
+}
+
+func init() {
+	implementationsCmd.Flags().AddFlagSet(ImplementationsFlags())
+	rootCmd.AddCommand(implementationsCmd)
+}
+
+func ImplementationsFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("implementations", pflag.ExitOnError)
+	fs.String("src-dir", "./", "directory of the interfaces and the packages searched for implementations")
+	fs.Bool("write", false, "write the assertions of every package with implementations")
+	fs.String("file", parser.DefaultAssertionsFile, "name of the assertions file written to each package")
+	fs.AddFlagSet(SelectionFlags())
+	fs.AddFlagSet(OutputFlags())
+	return fs
+}
+
+func ImplementationsRunner(cmd *cobra.Command, args []string) error {
+	sel, err := selectionFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	gofiles, err := parser.FindInterfaces(viper.GetString("src-dir"), sel)
+	if err != nil {
+		return err
+	}
+	found, err := parser.FindImplementations(cmd.Context(), gofiles, parser.ImplementationsOptions{
+		SearchDir:      viper.GetString("src-dir"),
+		AssertionsFile: viper.GetString("file"),
+		BuildTags:      sel.BuildTags,
+	})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	for _, i := range found.List {
+		typeName := i.Type
+		if i.Pointer {
+			typeName = "*" + typeName
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", i.Interface, typeName, i.Pos)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !viper.GetBool("write") && !viper.GetBool("check") && !viper.GetBool("diff") {
+		return nil
+	}
+	return writeOrCheck(cmd, found.Assertions)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Seann-Moser/interfacery/pkg/parser"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/spf13/cobra"
+)
+
+// inspectCmd prints the model the generators are rendered from
+var inspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Print the resolved model of the selected interfaces as JSON or YAML",
+	Long: `Prints every selected interface with its methods, their inferred HTTP method,
+URL path and param bindings, the param and return types, doc comments and the
+named types the methods use. Targets come from the project config when it exists,
+otherwise from the --src-dir and selection flags.`,
+	Args: cobra.NoArgs,
+	RunE: InspectRunner,
+}
+
+func init() {
+	inspectCmd.Flags().AddFlagSet(InspectFlags())
+	rootCmd.AddCommand(inspectCmd)
+}
+
+func InspectFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("inspect", pflag.ExitOnError)
+	fs.String("src-dir", "./", "")
+	fs.String("dest-dir", "./pkg/client", "generated output directory, excluded from the scan")
+	fs.String("config", parser.DefaultConfigFile, "project config listing the generation targets")
+	fs.String("format", "json", "output format: json or yaml")
+	fs.AddFlagSet(SelectionFlags())
+	return fs
+}
+
+func InspectRunner(cmd *cobra.Command, args []string) error {
+	format := viper.GetString("format")
+	if format != "json" && format != "yaml" {
+		return fmt.Errorf("unknown format %q, expected json or yaml", format)
+	}
+	targets, err := loadTargets(cmd)
+	if err != nil {
+		return err
+	}
+	model, err := parser.NewGenerator(parser.WithTargets(targets...)).Inspect(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if format == "yaml" {
+		enc := yaml.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent(2)
+		if err := enc.Encode(model); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(model)
+}
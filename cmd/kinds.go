@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// kindsCmd is a help topic describing the generator kinds of the generate command
+var kindsCmd = &cobra.Command{
+	Use:   "kinds",
+	Short: "Generator kinds and their options",
+	Long: `The handler and client kinds write the HTTP handlers and client of the handler
+and client commands, with the route_prefix of the target prefixing every route.
+Params are sent in the query string unless path_params is set, which binds the
+params named after the By of a method name to the path, as in GetUserByID
+served at /userservice/user/{id}. The handlers then read them with gorilla/mux.
+
+The proto kind writes a .proto file with a service for every interface, and
+the grpc kind writes a gRPC server calling the implementation and a client
+satisfying the interface. Both place the protoc-gen-go output in the
+<output>/<interface>pb package; the go_package option of a target changes the
+import path it is placed under and proto_package the proto package prefix:
+
+  - name: user-grpc
+    sources: [./pkg/users]
+    kind: grpc
+    output: ./pkg/grpcapi
+    options:
+      proto_package: example.users
+
+The connect kind serves every method as a Connect protocol unary procedure,
+POST /<package>.<Interface>/<Method> with JSON messages, and connect-client
+writes the matching client. The package is the Go package name of the
+interface unless the proto_package option is set.
+
+The jsonrpc kind writes a JSON-RPC 2.0 server for every interface, with the
+methods named <Interface>.<Method>. It serves POST requests over HTTP and
+newline delimited messages over any io.ReadWriteCloser such as stdin/stdout or
+a unix socket, and answers batches and notifications. jsonrpc-client writes a
+client over either transport, sending methods without returns as
+notifications.
+
+The mcp kind exposes every method as a Model Context Protocol tool, served over
+stdio and streamable HTTP. The JSON Schemas of the tool arguments and results
+are derived from the params and returns, and the descriptions from the doc
+comments of the methods.
+
+The cli kind writes a cobra command with a subcommand per method, see the cli
+command. Its client_package option names the package of the generated HTTP
+client that --url calls.
+
+The python kind writes a <interface>_client.py module per interface, making the
+output directory a Python package. Each module holds dataclasses for the struct
+types, a client calling the handler routes through httpx or requests, and
+exceptions for the HTTP error responses.
+
+The graphql kind writes a GraphQL schema per interface. Methods inferred as GET
+requests become query fields and the others mutations, with object and input
+types for the structs they use. graphql-resolvers writes the resolvers of those
+fields calling the implementation, keyed by "Query.<field>" and
+"Mutation.<field>" so they plug into any GraphQL executor.
+
+The fake kind writes a thread-safe in-memory implementation of every interface
+for tests, storing the structs with an ID field in maps. Methods are
+implemented from their names: Create assigns IDs, Get, Update and Delete look
+values up by their ID param, and List filters on the params named after fields
+and pages with limit and offset. Other methods call the Fallback field.
+
+The recorder kind writes a recorder wrapping an implementation, which writes
+every call with its arguments, results and error as a line of JSON, and a
+replayer answering calls from those lines. Calls are matched on their
+arguments, and calls without a matching recording fail.
+
+The resilience kind wraps an implementation with per-method retry policies
+(attempts, exponential backoff, jitter and a retryable error predicate) and a
+circuit breaker shared by the methods. Only idempotent methods are retried by
+default: those whose names start with Get, List, Put, Update, Delete or
+Remove, or marked with an //interfacery:idempotent directive. An
+//interfacery:nonidempotent directive opts a method out:
+
+  type UserService interface {
+  	//interfacery:idempotent
+  	SendEmail(ctx context.Context, id string) error
+  }`,
+}
+
+func init() {
+	rootCmd.AddCommand(kindsCmd)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/Seann-Moser/interfacery/pkg/parser"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/spf13/cobra"
+)
+
+// templatesCmd groups the commands for customizing the built-in templates
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List, eject and validate generator templates",
+}
+
+var templatesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the built-in templates",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "KIND\tTEMPLATE")
+		for _, t := range parser.BuiltinTemplates() {
+			_, _ = fmt.Fprintf(w, "%s\t%s\n", t.Kind, t.Name)
+		}
+		return w.Flush()
+	},
+}
+
+var templatesEjectCmd = &cobra.Command{
+	Use:   "eject <name> <dir>",
+	Short: "Write a built-in template to a directory for customization",
+	Long: `Writes the built-in template for a generator kind (or "all") to dir. Edit the
+copy and pass the directory back with --template, other .txt and .tmpl files in
+the directory can be included from the template as partials.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		written, err := parser.EjectTemplates(args[0], args[1], viper.GetBool("force"))
+		for _, filePath := range written {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), filePath)
+		}
+		return err
+	},
+}
+
+var templatesValidateCmd = &cobra.Command{
+	Use:   "validate <template>",
+	Short: "Render a template file or directory against a sample interface",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		kind := viper.GetString("kind")
+		source, partials, err := parser.LoadTemplateOverride(args[0], kind)
+		if err != nil {
+			return err
+		}
+		out, err := parser.ValidateTemplate(kind, source, partials)
+		if viper.GetBool("print") && out != nil {
+			_, _ = cmd.OutOrStdout().Write(out)
+		}
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s template %s is valid\n", kind, args[0])
+		return nil
+	},
+}
+
+var templatesFuncsCmd = &cobra.Command{
+	Use:   "funcs",
+	Short: "List the functions available to templates",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		for _, f := range parser.TemplateFunctions() {
+			_, _ = fmt.Fprintf(w, "%s\t%s\n", f.Usage, f.Doc)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	templatesEjectCmd.Flags().AddFlagSet(TemplatesEjectFlags())
+	templatesValidateCmd.Flags().AddFlagSet(TemplatesValidateFlags())
+	templatesCmd.AddCommand(templatesListCmd, templatesEjectCmd, templatesValidateCmd, templatesFuncsCmd)
+	rootCmd.AddCommand(templatesCmd)
+}
+
+func TemplatesEjectFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("templates-eject", pflag.ExitOnError)
+	fs.Bool("force", false, "overwrite existing files")
+	return fs
+}
+
+func TemplatesValidateFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("templates-validate", pflag.ExitOnError)
+	fs.String("kind", "handler", "generator kind the template is for")
+	fs.Bool("print", false, "print the rendered sample")
+	return fs
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/Seann-Moser/interfacery/pkg/parser"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/spf13/cobra"
+)
+
+// watchCmd regenerates outputs whenever the interfaces they come from change
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Regenerate outputs whenever their source interfaces change",
+	Long: `Renders every target once and then watches the scanned source directories.
+Only the packages whose files changed, and the packages importing them, are
+regenerated, and errors are reported without exiting. Targets come from the
+project config when it exists, otherwise from the --src-dir, --dest-dir and
+selection flags.`,
+	RunE: WatchRunner,
+}
+
+func init() {
+	watchCmd.Flags().AddFlagSet(WatchFlags())
+	rootCmd.AddCommand(watchCmd)
+}
+
+func WatchFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("watch", pflag.ExitOnError)
+	fs.String("src-dir", "./", "")
+	fs.String("dest-dir", "./pkg/client", "")
+	fs.String("template", "", "template file, or directory of templates, overriding the built-in templates")
+	fs.String("config", parser.DefaultConfigFile, "project config listing the generation targets")
+	fs.Duration("debounce", parser.DefaultDebounce, "how long to wait for a burst of changes to settle")
+	fs.AddFlagSet(SelectionFlags())
+	return fs
+}
+
+func WatchRunner(cmd *cobra.Command, args []string) error {
+	targets, err := loadTargets(cmd)
+	if err != nil {
+		return err
+	}
+	return parser.NewWatcher(targets, viper.GetDuration("debounce")).Run(cmd.Context())
+}
+
+// loadTargets reads the targets from the project config, falling back to a
+// single handler target built from the flags when the config does not exist.
+func loadTargets(cmd *cobra.Command) ([]parser.Target, error) {
+	configPath := viper.GetString("config")
+	if _, err := os.Stat(configPath); err == nil {
+		config, err := parser.LoadConfig(configPath)
+		if err != nil {
+			return nil, err
+		}
+		return config.Targets, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	sel, err := selectionFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+	// The generator fills in the defaults of the target and validates it like the config targets
+	return parser.NewGenerator(parser.WithTargets(parser.Target{
+		Name:       "default",
+		Sources:    []string{viper.GetString("src-dir")},
+		Interfaces: sel.Include,
+		Exclude:    sel.Exclude,
+		Marked:     sel.Marked,
+		Unexported: sel.Unexported,
+		Tests:      sel.Tests,
+		Tags:       sel.BuildTags,
+		Kind:       "handler",
+		Output:     viper.GetString("dest-dir"),
+		Template:   viper.GetString("template"),
+	})).Targets()
+}
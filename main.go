@@ -3,8 +3,14 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 */
 package main
 
-import "github.com/Seann-Moser/interfacery/cmd"
+import (
+	"os"
+
+	"github.com/Seann-Moser/interfacery/cmd"
+)
 
 func main() {
-	_ = cmd.Execute()
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
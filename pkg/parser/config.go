@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile is the project config file looked up by the generate command.
+const DefaultConfigFile = "interfacery.yaml"
+
+// Config describes every generation target of a project.
+type Config struct {
+	Targets []Target `yaml:"targets"`
+}
+
+// Target is a single generation run: which interfaces to read and what to generate from them.
+type Target struct {
+	Name        string            `yaml:"name"`
+	Sources     []string          `yaml:"sources"`      // Directories searched for interfaces
+	Interfaces  []string          `yaml:"interfaces"`   // Interface patterns, all interfaces when empty, see Selection
+	Exclude     []string          `yaml:"exclude"`      // Interface patterns removed from the selection
+	Marked      bool              `yaml:"marked"`       // Only select interfaces with a MarkerComment
+	Unexported  bool              `yaml:"unexported"`   // Also select unexported interfaces
+	Tests       bool              `yaml:"tests"`        // Also scan _test.go files
+	Tags        []string          `yaml:"tags"`         // Build tags considered satisfied when reading the sources
+	Kind        string            `yaml:"kind"`         // Generator kind, see GeneratorKinds
+	Output      string            `yaml:"output"`       // Output directory
+	Package     string            `yaml:"package"`      // Package name, defaults to the base name of Output
+	Template    string            `yaml:"template"`     // Template file or directory overriding the built-in template
+	RoutePrefix string            `yaml:"route_prefix"` // Prepended to every inferred URL path
+	PathParams  bool              `yaml:"path_params"`  // Bind the params a route names to its path, such as id in /user/{id}
+	Options     map[string]string `yaml:"options"`      // Generator specific options, passed to templates and plugins
+}
+
+// LoadConfig reads a project config. Relative paths in the config are
+// resolved against the directory containing the config file.
+func LoadConfig(configPath string) (*Config, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true) // Misspelled keys would otherwise be silently ignored
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+
+	baseDir := filepath.Dir(configPath)
+	for i := range config.Targets {
+		t := &config.Targets[i]
+		if err := t.setDefaults(i); err != nil {
+			return nil, err
+		}
+		for j, source := range t.Sources {
+			t.Sources[j] = resolvePath(baseDir, source)
+		}
+		for j, pattern := range t.Interfaces {
+			t.Interfaces[j] = resolveSelector(baseDir, pattern)
+		}
+		for j, pattern := range t.Exclude {
+			t.Exclude[j] = resolveSelector(baseDir, pattern)
+		}
+		t.Output = resolvePath(baseDir, t.Output)
+		if t.Template != "" {
+			t.Template = resolvePath(baseDir, t.Template)
+		}
+	}
+	return &config, nil
+}
+
+// setDefaults fills in the defaults of the index-th target and checks that it is complete.
+func (t *Target) setDefaults(index int) error {
+	if t.Name == "" {
+		t.Name = fmt.Sprintf("target-%d", index)
+	}
+	if t.Kind == "" {
+		t.Kind = "handler"
+	}
+	if _, err := getGeneratorKind(t.Kind); err != nil {
+		return fmt.Errorf("target %s: %w", t.Name, err)
+	}
+	if t.Output == "" {
+		return fmt.Errorf("target %s: output is required", t.Name)
+	}
+	if len(t.Sources) == 0 {
+		t.Sources = []string{"./"}
+	}
+	return nil
+}
+
+func resolvePath(baseDir, p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(baseDir, p)
+}
+
+// Selection returns the interfaces selected by the target.
+func (t Target) Selection() Selection {
+	return Selection{
+		Include:    t.Interfaces,
+		Exclude:    t.Exclude,
+		Marked:     t.Marked,
+		Unexported: t.Unexported,
+		Tests:      t.Tests,
+		BuildTags:  t.Tags,
+	}
+}
+
+// targetRenderJobs scans the sources of t and returns a job for every file with selected
+// interfaces. When dirs is not nil, only the packages in those directories are included.
+func targetRenderJobs(t Target, dirs map[string]bool) ([]RenderJob, error) {
+	newTemplate, partials, err := readTargetTemplate(t)
+	if err != nil {
+		return nil, err
+	}
+	opts := RenderOptions{
+		Kind:        t.Kind,
+		OutputDir:   t.Output,
+		PackageName: t.Package,
+		Template:    newTemplate,
+		Partials:    partials,
+		RoutePrefix: t.RoutePrefix,
+		PathParams:  t.PathParams,
+		Options:     t.Options,
+	}
+
+	var jobs []RenderJob
+	for _, source := range t.Sources {
+		gofiles, err := FindInterfaces(source, t.Selection(), t.Output)
+		if err != nil {
+			return nil, fmt.Errorf("target %s: %w", t.Name, err)
+		}
+		for _, gofile := range gofiles {
+			if dirs != nil && !dirs[gofile.Dir] {
+				continue
+			}
+			jobs = append(jobs, RenderJob{File: gofile, Options: opts})
+		}
+	}
+	return jobs, nil
+}
+
+func readTargetTemplate(t Target) (string, map[string]string, error) {
+	if t.Template == "" {
+		return "", nil, nil
+	}
+	source, partials, err := LoadTemplateOverride(t.Template, t.Kind)
+	if err != nil {
+		return "", nil, fmt.Errorf("target %s: failed to read template: %w", t.Name, err)
+	}
+	return source, partials, nil
+}
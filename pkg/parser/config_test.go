@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, DefaultConfigFile)
+	err := os.WriteFile(configPath, []byte(`
+targets:
+  - name: users
+    sources: [./pkg/users]
+    interfaces: [UserService]
+    exclude: [./pkg/users.Internal*]
+    kind: client
+    output: ./pkg/client
+    package: userclient
+    template: ./templates/client.tmpl
+    route_prefix: /api
+  - output: /abs/handlers
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Target{
+		{
+			Name:        "users",
+			Sources:     []string{filepath.Join(dir, "pkg/users")},
+			Interfaces:  []string{"UserService"},
+			Exclude:     []string{filepath.ToSlash(filepath.Join(dir, "pkg/users")) + ".Internal*"},
+			Kind:        "client",
+			Output:      filepath.Join(dir, "pkg/client"),
+			Package:     "userclient",
+			Template:    filepath.Join(dir, "templates/client.tmpl"),
+			RoutePrefix: "/api",
+		},
+		{
+			Name:    "target-1",
+			Sources: []string{dir},
+			Kind:    "handler",
+			Output:  "/abs/handlers",
+		},
+	}
+	if !reflect.DeepEqual(config.Targets, expected) {
+		t.Errorf("got %+v, want %+v", config.Targets, expected)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"UnknownKind", "targets:\n  - kind: nope\n    output: ./out\n"},
+		{"MissingOutput", "targets:\n  - kind: handler\n"},
+		{"InvalidYAML", "targets: [\n"},
+		{"UnknownKey", "targets:\n  - kind: handler\n    output: ./out\n    ouptut_package: api\n"},
+		{"UnknownTopLevelKey", "target:\n  - kind: handler\n    output: ./out\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), DefaultConfigFile)
+			if err := os.WriteFile(configPath, []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadConfig(configPath); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
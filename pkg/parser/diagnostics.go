@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Severity of a Diagnostic.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found while loading or rendering.
+type Diagnostic struct {
+	Severity  Severity `json:"severity" yaml:"severity"`
+	Target    string   `json:"target,omitempty" yaml:"target,omitempty"`
+	Interface string   `json:"interface,omitempty" yaml:"interface,omitempty"`
+	Pos       string   `json:"pos,omitempty" yaml:"pos,omitempty"` // file:line:column when the problem has a source position
+	Message   string   `json:"message" yaml:"message"`
+}
+
+func (d Diagnostic) String() string {
+	s := string(d.Severity) + ": "
+	if d.Pos != "" {
+		s = d.Pos + ": " + s
+	}
+	if d.Interface != "" {
+		s += d.Interface + ": "
+	}
+	return s + d.Message
+}
+
+// diagnostics collects the diagnostics of a run. A nil *diagnostics discards them.
+type diagnostics struct {
+	mu   sync.Mutex
+	list []Diagnostic
+}
+
+func (d *diagnostics) add(diag Diagnostic) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.list = append(d.list, diag)
+}
+
+func (d *diagnostics) addf(severity Severity, name, format string, args ...any) {
+	d.add(Diagnostic{Severity: severity, Interface: name, Message: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,259 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ExtractOptions configures ExtractInterface.
+type ExtractOptions struct {
+	Type      string   // Package qualified type, such as ./pkg/store.Store or example.com/app/pkg/store.Store
+	Name      string   // Name of the interface, <Type>Interface when empty
+	Include   []string // Methods to keep as names, globs or /regexp/, every exported method when empty
+	Exclude   []string // Methods to leave out, using the same patterns as Include
+	Output    string   // File the interface is written to, <name>.go next to the type when empty
+	BuildTags []string // Additional build tags satisfied when loading the package
+}
+
+// ExtractInterface renders an interface declaring the exported methods of a
+// concrete type, in declaration order and with their doc comments. The file
+// also asserts that the type implements it, so the two can not drift apart.
+func ExtractInterface(ctx context.Context, opts ExtractOptions) (GeneratedFile, error) {
+	qualifier, typeName := splitSelector(opts.Type)
+	if qualifier == "" || typeName == "" {
+		return GeneratedFile{}, fmt.Errorf("invalid type %q, expected a package qualified type such as ./pkg/store.Store", opts.Type)
+	}
+	include, err := methodSelectors(opts.Include)
+	if err != nil {
+		return GeneratedFile{}, err
+	}
+	exclude, err := methodSelectors(opts.Exclude)
+	if err != nil {
+		return GeneratedFile{}, err
+	}
+
+	name := orFunc(opts.Name, typeName+"Interface")
+	file := FileInterface{ImportName: qualifier, BuildTags: opts.BuildTags}
+	if isDirQualifier(qualifier) {
+		file.Dir, err = filepath.Abs(filepath.FromSlash(qualifier))
+	} else if p, findErr := build.Default.Import(qualifier, ".", build.FindOnly); findErr == nil {
+		file.Dir = p.Dir
+	}
+	if err != nil {
+		return GeneratedFile{}, err
+	}
+	output := opts.Output
+	if output == "" {
+		if file.Dir == "" {
+			return GeneratedFile{}, fmt.Errorf("could not find the directory of %s, set an output file", qualifier)
+		}
+		output = filepath.Join(file.Dir, toSnakeCase(name)+".go")
+	}
+	if output, err = filepath.Abs(output); err != nil {
+		return GeneratedFile{}, err
+	}
+
+	// A previous extraction asserts the old method set, load the package without it
+	loaded, err := loadPackages(ctx, []FileInterface{file}, nil, loadOptions{overlay: packageClauseOverlay(output)})
+	if err != nil {
+		return GeneratedFile{}, err
+	}
+	pkg, err := loaded.Lookup(file)
+	if err != nil {
+		return GeneratedFile{}, err
+	}
+	obj, ok := pkg.Types.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return GeneratedFile{}, fmt.Errorf("type %s not found in %s", typeName, pkg.PkgPath)
+	}
+	named, ok := obj.Type().(*types.Named)
+	switch {
+	case !ok || obj.IsAlias():
+		return GeneratedFile{}, fmt.Errorf("%s.%s is not a defined type", pkg.PkgPath, typeName)
+	case types.IsInterface(named):
+		return GeneratedFile{}, fmt.Errorf("%s.%s is already an interface", pkg.PkgPath, typeName)
+	case named.TypeParams().Len() > 0:
+		return GeneratedFile{}, fmt.Errorf("%s.%s is generic, generic types are not supported", pkg.PkgPath, typeName)
+	}
+
+	// The output package refers to the types of other packages through imports
+	outPath, outName := pkg.PkgPath, pkg.Name
+	if outDir := filepath.Dir(output); outDir != filepath.Dir(pkg.GoFiles[0]) {
+		if outPath, _, err = newModuleResolver().importPath(outDir); err != nil {
+			return GeneratedFile{}, err
+		}
+		outName = outputPackageName(outDir, loaded.names, outPath)
+	}
+	im := newImporter(loaded.names)
+	qualify := func(p *types.Package) string {
+		if p.Path() == outPath {
+			return ""
+		}
+		return im.alias(p.Path())
+	}
+
+	var methods []*types.Func
+	set := types.NewMethodSet(types.NewPointer(named))
+	for i := 0; i < set.Len(); i++ {
+		m := set.At(i).Obj().(*types.Func)
+		if !m.Exported() || len(include) > 0 && !matchesMethod(include, m.Name()) || matchesMethod(exclude, m.Name()) {
+			continue
+		}
+		methods = append(methods, m)
+	}
+	if len(methods) == 0 {
+		return GeneratedFile{}, fmt.Errorf("%s.%s has no exported methods matching the filters", pkg.PkgPath, typeName)
+	}
+	sortByDeclaration(methods, pkg.Types)
+
+	docs := methodDocs(pkg)
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "// %s declares the exported methods of %s.\n", name, types.TypeString(named, qualify))
+	fmt.Fprintf(&body, "type %s interface {\n", name)
+	for i, m := range methods {
+		if doc := docs[m.Pos()]; doc != "" {
+			if i > 0 {
+				body.WriteString("\n")
+			}
+			writeComment(&body, "\t", doc)
+		}
+		body.WriteString("\t" + m.Name())
+		types.WriteSignature(&body, m.Type().(*types.Signature), qualify)
+		body.WriteString("\n")
+	}
+	body.WriteString("}\n\n")
+	fmt.Fprintf(&body, "var _ %s = (*%s)(nil)\n", name, types.TypeString(named, qualify))
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "package %s\n\n", outName)
+	writeImports(&src, im)
+	src.Write(body.Bytes())
+
+	content, err := formatGenerated(output, src.Bytes())
+	if err != nil {
+		return GeneratedFile{}, fmt.Errorf("failed to format the %s interface: %w", name, err)
+	}
+	return GeneratedFile{Path: output, Content: content, Interface: name}, nil
+}
+
+// sortByDeclaration sorts methods in declaration order. The methods declared
+// in pkg come first, then the ones promoted from other packages.
+func sortByDeclaration(methods []*types.Func, pkg *types.Package) {
+	sort.SliceStable(methods, func(i, j int) bool {
+		if local := methods[i].Pkg() == pkg; local != (methods[j].Pkg() == pkg) {
+			return local
+		}
+		return methods[i].Pos() < methods[j].Pos()
+	})
+}
+
+// methodSelectors compiles method name patterns, which are matched like interface names.
+func methodSelectors(patterns []string) ([]selector, error) {
+	var selectors []selector
+	for _, pattern := range patterns {
+		sel, err := parseSelector(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if sel.pkg != "" {
+			return nil, fmt.Errorf("invalid method pattern %q: methods can not be package qualified", pattern)
+		}
+		selectors = append(selectors, sel)
+	}
+	return selectors, nil
+}
+
+func matchesMethod(selectors []selector, name string) bool {
+	for _, sel := range selectors {
+		if sel.match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// methodDocs returns the doc comments of the methods declared in pkg and its
+// dependencies, by the position of their name. Promoted methods keep their docs.
+func methodDocs(pkg *packages.Package) map[token.Pos]string {
+	docs := map[token.Pos]string{}
+	packages.Visit([]*packages.Package{pkg}, nil, func(p *packages.Package) {
+		for _, file := range p.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.FuncDecl:
+					if n.Recv != nil && n.Doc != nil {
+						docs[n.Name.Pos()] = n.Doc.Text()
+					}
+					return false
+				case *ast.InterfaceType:
+					for _, field := range n.Methods.List {
+						if len(field.Names) > 0 && field.Doc != nil {
+							docs[field.Names[0].Pos()] = field.Doc.Text()
+						}
+					}
+				}
+				return true
+			})
+		}
+	})
+	return docs
+}
+
+// packageClauseOverlay replaces the existing Go file at path with just its package clause.
+func packageClauseOverlay(path string) map[string][]byte {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly)
+	if err != nil {
+		return nil
+	}
+	return map[string][]byte{path: []byte("package " + f.Name.Name + "\n")}
+}
+
+// outputPackageName returns the name of the package in dir, guessed from its
+// import path when the directory holds no Go files yet.
+func outputPackageName(dir string, names map[string]string, importPath string) string {
+	if p, err := build.Default.ImportDir(dir, 0); err == nil && p.Name != "" {
+		return p.Name
+	}
+	return newImporter(names).packageName(importPath)
+}
+
+// writeImports writes the import declaration of the packages im has assigned aliases to.
+func writeImports(b *bytes.Buffer, im *importer) {
+	paths, aliases := im.imports()
+	if len(paths) == 0 {
+		return
+	}
+	imported := make([]string, 0, len(paths))
+	for importPath := range paths {
+		imported = append(imported, importPath)
+	}
+	sort.Strings(imported)
+	b.WriteString("import (\n")
+	for _, importPath := range imported {
+		fmt.Fprintf(b, "\t%s %s\n", aliases[importPath], strconv.Quote(importPath))
+	}
+	b.WriteString(")\n\n")
+}
+
+// writeComment writes text as line comments with the given indent.
+func writeComment(b *bytes.Buffer, indent, text string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if line == "" {
+			b.WriteString(indent + "//\n")
+			continue
+		}
+		b.WriteString(indent + "// " + line + "\n")
+	}
+}
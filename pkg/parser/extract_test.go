@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractInterface(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "store", "store.go"), `package store
+
+import "context"
+
+type Item struct{ ID string }
+
+type Store struct{}
+
+// Get returns an item.
+func (s *Store) Get(ctx context.Context, id string) (*Item, error) { return nil, nil }
+
+func (s Store) List(ids ...string) []Item { return nil }
+
+func (s *Store) Close() error { return nil }
+
+func (s *Store) reset() {}
+`)
+	// A stale extraction must not keep the package from loading
+	writeTestFile(t, filepath.Join(root, "store", "store_interface.go"), "package store\n\nvar _ interface{ Removed() } = (*Store)(nil)\n")
+
+	tests := []struct {
+		name     string
+		opts     ExtractOptions
+		path     string
+		contains []string
+		missing  []string
+	}{
+		{
+			name: "same package",
+			opts: ExtractOptions{Type: filepath.Join(root, "store") + ".Store", Exclude: []string{"Close"}},
+			path: filepath.Join(root, "store", "store_interface.go"),
+			contains: []string{
+				"package store",
+				"type StoreInterface interface {\n\t// Get returns an item.\n\tGet(ctx context.Context, id string) (*Item, error)\n\tList(ids ...string) []Item\n}",
+				"var _ StoreInterface = (*Store)(nil)",
+			},
+			missing: []string{"Close", "reset", "Removed"},
+		},
+		{
+			name: "other package",
+			opts: ExtractOptions{Type: filepath.Join(root, "store") + ".Store", Name: "Getter", Include: []string{"G*"}, Output: filepath.Join(root, "api", "getter.go")},
+			path: filepath.Join(root, "api", "getter.go"),
+			contains: []string{
+				"package api",
+				`"example.com/root/store"`,
+				"Get(ctx context.Context, id string) (*store.Item, error)",
+				"var _ Getter = (*store.Store)(nil)",
+			},
+			missing: []string{"List"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ExtractInterface(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file.Path != tt.path {
+				t.Errorf("got path %s, want %s", file.Path, tt.path)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(file.Content), want) {
+					t.Errorf("got %s, want it to contain %s", file.Content, want)
+				}
+			}
+			for _, unwanted := range tt.missing {
+				if strings.Contains(string(file.Content), unwanted) {
+					t.Errorf("got %s, want it not to contain %s", file.Content, unwanted)
+				}
+			}
+			writeTestFile(t, file.Path, string(file.Content))
+		})
+	}
+
+	for _, typ := range []string{"Store", filepath.Join(root, "store") + ".Item", filepath.Join(root, "store") + ".Missing"} {
+		if _, err := ExtractInterface(context.Background(), ExtractOptions{Type: typ}); err == nil {
+			t.Errorf("%s: expected an error", typ)
+		}
+	}
+}
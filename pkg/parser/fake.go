@@ -0,0 +1,366 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FakeModel is the in-memory fake of an interface, as returned by the fakeModel template function.
+// Methods are implemented from their name, the words inferURLPath recognises: Get, List,
+// Create (or Add), Update, Put and Delete (or Remove), followed by the resource.
+type FakeModel struct {
+	Resources []FakeResource
+	Methods   []FakeMethod // Every method of the interface, Action is empty for those the fake can not implement
+}
+
+// FakeResource is a struct type the fake stores, keyed by its ID field.
+type FakeResource struct {
+	Name    string // Unique name of the resource, used to name the maps of the fake
+	Type    string // Go type of the stored values
+	IDField string
+	IDType  string
+	IDZero  string // Constant the ID field is compared with to tell whether it is set
+	NewID   string // Expression converting the int counter %s to a new ID
+}
+
+// FakeMethod is a method of the interface and how the fake implements it.
+type FakeMethod struct {
+	Action       string // Get, List, Create, Update, Put or Delete
+	Resource     FakeResource
+	ID           string // Param holding the ID, empty when it is the ID field of the value
+	Key          string // Expression of the ID the method looks up, the ID param or the ID field of the value
+	Value        string // Expression of the value Create, Update and Put store, empty for Delete without a value
+	ValueParam   string // Param holding the value, checked for nil when it is a pointer
+	Filters      []FakeFilter
+	Limit        string // Param limiting the number of List results, as an int expression
+	Offset       string // Param skipping List results, as an int expression
+	Total        bool   // List returns the number of matching values before paging, as total
+	UsesStored   bool   // Returns refers to the stored value
+	Returns      []string
+	ErrorReturns []string // Returns when the method fails, the error is Vars.Err
+	Vars         FakeVars
+	Method       Method
+}
+
+// FakeVars names the receiver and the locals of a method, numbered when a param uses the name.
+type FakeVars struct {
+	Receiver string // f
+	Stored   string // The value read or written
+	OK       string
+	Matched  string // The values List returns
+	Total    string
+	ID       string // The IDs List iterates over
+	Key      string
+	Err      string
+}
+
+// FakeFilter matches the values listed by List against a param. Zero values and empty slices match everything.
+type FakeFilter struct {
+	Param string
+	Field string
+	Zero  string
+	In    bool // The param is a slice, values match any of its elements
+}
+
+var fakeActions = map[string]string{
+	"Get":    "Get",
+	"List":   "List",
+	"Create": "Create",
+	"Add":    "Create",
+	"Update": "Update",
+	"Put":    "Put",
+	"Delete": "Delete",
+	"Remove": "Delete",
+}
+
+// fakeBuilder builds the FakeModel of an interface.
+type fakeBuilder struct {
+	im        *importer
+	helper    string // Prefix of the helpers of the generated file
+	defs      map[string]TypeDef
+	resources map[string]*FakeResource // By Go type
+	order     []string
+	taken     map[string]bool
+}
+
+// buildFakeModel returns the in-memory fake of the interface rendered with r.
+func buildFakeModel(im *importer, r TemplateReplace) *FakeModel {
+	b := &fakeBuilder{
+		im:        im,
+		helper:    lowerFirst(r.InterfaceName),
+		defs:      map[string]TypeDef{},
+		resources: map[string]*FakeResource{},
+		taken:     map[string]bool{},
+	}
+	for _, def := range r.Types {
+		b.defs[im.qualify(def.Name)] = def
+	}
+
+	// Resources are the values Get, List, Create, Update and Put read or write
+	for _, m := range r.Methods {
+		switch fakeAction(m.Name) {
+		case "Get":
+			b.resource(b.firstReturn(m, false))
+		case "List":
+			b.resource(b.firstReturn(m, true))
+		case "Create", "Update", "Put":
+			b.resource(b.valueParam(m).Type)
+		}
+	}
+
+	model := &FakeModel{}
+	for _, t := range b.order {
+		model.Resources = append(model.Resources, *b.resources[t])
+	}
+	for _, m := range r.Methods {
+		model.Methods = append(model.Methods, b.method(m))
+	}
+	return model
+}
+
+// fakeAction returns the action of a method name, empty when it does not start with one.
+func fakeAction(methodName string) string {
+	return fakeActions[splitCamelCase(methodName)[0]]
+}
+
+// method returns how the fake implements m.
+func (b *fakeBuilder) method(m Method) FakeMethod {
+	v := FakeVars{
+		Receiver: getUniqueVarName("f", m.Params),
+		Stored:   getUniqueVarName("stored", m.Params),
+		OK:       getUniqueVarName("ok", m.Params),
+		Matched:  getUniqueVarName("matched", m.Params),
+		Total:    getUniqueVarName("total", m.Params),
+		ID:       getUniqueVarName("id", m.Params),
+		Key:      getUniqueVarName("key", m.Params),
+		Err:      getUniqueVarName("err", m.Params),
+	}
+	method := FakeMethod{Method: m, Vars: v}
+	for _, ret := range m.Returns {
+		if ret.Type == "error" {
+			method.ErrorReturns = append(method.ErrorReturns, v.Err)
+		} else {
+			method.ErrorReturns = append(method.ErrorReturns, zeroValue(ret.Type))
+		}
+	}
+	action := fakeAction(m.Name)
+	var res *FakeResource
+	switch action {
+	case "Get":
+		res = b.resources[derefType(b.firstReturn(m, false))]
+	case "List":
+		res = b.resources[derefType(b.firstReturn(m, true))]
+	case "Create", "Update", "Put", "Delete":
+		if p := b.valueParam(m); p.Name != "" {
+			res = b.resources[derefType(p.Type)]
+			method.Value, method.ValueParam = p.Name, p.Name
+			if isPointerType(p.Type) {
+				method.Value = "*" + p.Name
+			}
+		} else if action == "Delete" {
+			res = b.resourceNamed(m.Name)
+		}
+	}
+	if res == nil {
+		return method
+	}
+
+	idParam := b.idParam(m, *res)
+	switch {
+	case action == "Get" && idParam == "", action == "Delete" && idParam == "" && method.Value == "":
+		return method
+	case idParam != "":
+		method.ID, method.Key = idParam, idParam
+	default:
+		method.Key = v.Stored + "." + res.IDField
+	}
+	if action == "List" {
+		b.listParams(m, *res, &method)
+	}
+	method.Action, method.Resource = action, *res
+
+	for _, ret := range m.Returns {
+		var value string
+		switch {
+		case ret.Type == "error":
+			value = "nil"
+		case action == "List" && ret.Type == "[]"+res.Type:
+			value = v.Matched
+		case action == "List" && ret.Type == "[]*"+res.Type:
+			value = b.helper + "FakePointers(" + v.Matched + ")"
+		case action == "List" && isNumericType(ret.Type) && !method.Total:
+			value, method.Total = ret.Type+"("+v.Total+")", true
+			if ret.Type == "int" {
+				value = v.Total
+			}
+		case action != "List" && ret.Type == res.Type:
+			value, method.UsesStored = v.Stored, true
+		case action != "List" && ret.Type == "*"+res.Type:
+			value, method.UsesStored = "&"+v.Stored, true
+		case action != "List" && action != "Get" && ret.Type == res.IDType:
+			value, method.UsesStored = v.Stored+"."+res.IDField, true
+		default:
+			value = zeroValue(ret.Type)
+		}
+		method.Returns = append(method.Returns, value)
+	}
+	return method
+}
+
+// resource registers the struct type t, or the type t points to, when it has an ID field.
+func (b *fakeBuilder) resource(t string) {
+	t = derefType(t)
+	def, ok := b.defs[t]
+	if !ok || def.Kind != "struct" || b.resources[t] != nil {
+		return
+	}
+	name := typeName(t)
+	for _, f := range def.Fields {
+		if !strings.EqualFold(f.Name, "id") && !strings.EqualFold(f.JSONName, "id") && f.Name != name+"ID" {
+			continue
+		}
+		res := &FakeResource{Type: t, IDField: f.Name, IDType: b.im.qualify(f.Type)}
+		switch kind := b.underlying(res.IDType); {
+		case kind == "string":
+			res.IDZero, res.NewID = `""`, "strconv.Itoa(%s)"
+		case isNumericType(kind) && !strings.HasPrefix(kind, "float"):
+			res.IDZero, res.NewID = "0", "%s"
+		default:
+			continue
+		}
+		if res.IDType != "string" && res.IDType != "int" {
+			res.NewID = res.IDType + "(" + res.NewID + ")"
+		}
+		res.Name = lowerFirst(name)
+		if b.taken[res.Name] {
+			res.Name = lowerFirst(toPascalCase(typePackage(t)) + name)
+			for i := 2; b.taken[res.Name]; i++ {
+				res.Name = fmt.Sprintf("%s%s%d", lowerFirst(toPascalCase(typePackage(t))), name, i)
+			}
+		}
+		b.taken[res.Name] = true
+		b.resources[t] = res
+		b.order = append(b.order, t)
+		return
+	}
+}
+
+// resourceNamed returns the resource named by the words of the method name after its action.
+func (b *fakeBuilder) resourceNamed(methodName string) *FakeResource {
+	words := splitCamelCase(methodName)[1:]
+	for i, word := range words {
+		if word == "By" {
+			words = words[:i]
+			break
+		}
+	}
+	name := strings.Join(words, "")
+	for _, t := range b.order {
+		if n := typeName(t); n == name || n+"s" == name {
+			return b.resources[t]
+		}
+	}
+	if len(b.order) == 1 {
+		return b.resources[b.order[0]]
+	}
+	return nil
+}
+
+// firstReturn returns the type of the first return that is not an error, its element when list is set.
+func (b *fakeBuilder) firstReturn(m Method, list bool) string {
+	for _, ret := range m.Returns {
+		if ret.Type == "error" {
+			continue
+		}
+		if list {
+			if !isSliceType(ret.Type) {
+				return ""
+			}
+			return elemType(ret.Type)
+		}
+		return ret.Type
+	}
+	return ""
+}
+
+// valueParam returns the param holding a resource, or a stored struct, as a value or pointer.
+func (b *fakeBuilder) valueParam(m Method) Param {
+	for _, p := range m.Params {
+		if def, ok := b.defs[derefType(p.Type)]; ok && def.Kind == "struct" && !isVariadicType(p.Type) {
+			return p
+		}
+	}
+	return Param{}
+}
+
+// idParam returns the param holding the ID of res, named id or ending in id like inferURLPath expects.
+func (b *fakeBuilder) idParam(m Method, res FakeResource) string {
+	for _, p := range m.Params {
+		if name := strings.ToLower(p.Name); (name == "id" || strings.HasSuffix(name, "id")) && p.Type == res.IDType {
+			return p.Name
+		}
+	}
+	return ""
+}
+
+// listParams assigns the params of a List method to paging and filters on the fields of res.
+func (b *fakeBuilder) listParams(m Method, res FakeResource, method *FakeMethod) {
+	def := b.defs[res.Type]
+	for _, p := range m.Params {
+		name := strings.ToLower(p.Name)
+		if (name == "limit" || name == "offset") && isNumericType(p.Type) && !strings.HasPrefix(p.Type, "float") {
+			expr := p.Name
+			if p.Type != "int" {
+				expr = "int(" + p.Name + ")"
+			}
+			if name == "limit" {
+				method.Limit = expr
+			} else {
+				method.Offset = expr
+			}
+			continue
+		}
+		in := isSliceType(p.Type) || isVariadicType(p.Type)
+		for _, f := range def.Fields {
+			fieldType := b.im.qualify(f.Type)
+			if !b.comparable(fieldType) {
+				continue
+			}
+			switch {
+			case !in && p.Type == fieldType && (strings.EqualFold(f.Name, p.Name) || strings.EqualFold(f.JSONName, p.Name)):
+				method.Filters = append(method.Filters, FakeFilter{Param: p.Name, Field: f.Name, Zero: zeroValue(b.underlying(fieldType))})
+			case in && elemType(p.Type) == fieldType && (strings.EqualFold(f.Name, p.Name) || strings.EqualFold(f.Name+"s", p.Name)):
+				method.Filters = append(method.Filters, FakeFilter{Param: p.Name, Field: f.Name, In: true})
+			default:
+				continue
+			}
+			break
+		}
+	}
+}
+
+// comparable reports whether values of t can be filtered on, predeclared types and the types defined from them.
+func (b *fakeBuilder) comparable(t string) bool {
+	kind := b.underlying(t)
+	return isBasicType(kind) && kind != "any"
+}
+
+// underlying returns the underlying type of the named type t, or t itself.
+func (b *fakeBuilder) underlying(t string) string {
+	for i := 0; i < 10; i++ {
+		def, ok := b.defs[t]
+		if !ok || def.Kind == "struct" || def.Kind == "interface" {
+			return t
+		}
+		t = b.im.qualify(def.Underlying)
+	}
+	return t
+}
+
+// derefType removes one level of pointer from t.
+func derefType(t string) string {
+	if isPointerType(t) {
+		return elemType(t)
+	}
+	return t
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFakeModel(t *testing.T) {
+	im := newImporter(nil)
+	im.reserve("example.com/app/users", "users")
+	model := buildFakeModel(im, TemplateReplace{
+		PackageName:   "users",
+		InterfaceName: "UserService",
+		Methods: []Method{{
+			Name:    "CreateUser",
+			Params:  []Param{{Name: "user", Type: "*users.User"}},
+			Returns: []Return{{Type: "users.UserID"}, {Type: "error"}},
+		}, {
+			Name:    "GetUser",
+			Params:  []Param{{Name: "userID", Type: "users.UserID"}},
+			Returns: []Return{{Type: "*users.User"}, {Type: "error"}},
+		}, {
+			Name:    "ListUsers",
+			Params:  []Param{{Name: "role", Type: "users.Role"}, {Name: "names", Type: "...string"}, {Name: "limit", Type: "int32"}},
+			Returns: []Return{{Type: "[]*users.User"}, {Type: "int"}, {Type: "error"}},
+		}, {
+			Name:    "RemoveUser",
+			Params:  []Param{{Name: "id", Type: "users.UserID"}},
+			Returns: []Return{{Type: "error"}},
+		}, {
+			Name:    "Search",
+			Params:  []Param{{Name: "f", Type: "string"}, {Name: "stored", Type: "bool"}},
+			Returns: []Return{{Type: "[]users.User"}, {Type: "error"}},
+		}},
+		Types: []TypeDef{
+			{Name: "example.com/app/users.Role", Kind: "basic", Underlying: "string"},
+			{Name: "example.com/app/users.UserID", Kind: "basic", Underlying: "int64"},
+			{Name: "example.com/app/users.User", Kind: "struct", Fields: []Field{
+				{Name: "UserID", Type: "example.com/app/users.UserID", JSONName: "id"},
+				{Name: "Name", Type: "string", JSONName: "name"},
+				{Name: "Role", Type: "example.com/app/users.Role", JSONName: "role"},
+			}},
+		},
+	})
+
+	user := FakeResource{Name: "user", Type: "users.User", IDField: "UserID", IDType: "users.UserID", IDZero: "0", NewID: "users.UserID(%s)"}
+	if !reflect.DeepEqual(model.Resources, []FakeResource{user}) {
+		t.Fatalf("got resources %+v, want %+v", model.Resources, user)
+	}
+	for i := range model.Methods {
+		model.Methods[i].Method = Method{}
+	}
+	vars := FakeVars{Receiver: "f", Stored: "stored", OK: "ok", Matched: "matched", Total: "total", ID: "id", Key: "key", Err: "err"}
+	// The locals are numbered when a param uses their name
+	searchVars := vars
+	searchVars.Receiver, searchVars.Stored = "f1", "stored1"
+	deleteVars := vars
+	deleteVars.ID = "id1"
+	expected := []FakeMethod{{
+		Action: "Create", Resource: user, Key: "stored.UserID", Value: "*user", ValueParam: "user", UsesStored: true,
+		Returns: []string{"stored.UserID", "nil"}, ErrorReturns: []string{"*new(users.UserID)", "err"}, Vars: vars,
+	}, {
+		Action: "Get", Resource: user, ID: "userID", Key: "userID", UsesStored: true,
+		Returns: []string{"&stored", "nil"}, ErrorReturns: []string{"nil", "err"}, Vars: vars,
+	}, {
+		Action: "List", Resource: user, Key: "stored.UserID", Limit: "int(limit)", Total: true,
+		Filters: []FakeFilter{{Param: "role", Field: "Role", Zero: `""`}, {Param: "names", Field: "Name", In: true}},
+		Returns: []string{"userServiceFakePointers(matched)", "total", "nil"}, ErrorReturns: []string{"nil", "0", "err"}, Vars: vars,
+	}, {
+		Action: "Delete", Resource: user, ID: "id", Key: "id",
+		Returns: []string{"nil"}, ErrorReturns: []string{"err"}, Vars: deleteVars,
+	}, {
+		ErrorReturns: []string{"nil", "err"}, Vars: searchVars,
+	}}
+	for i, method := range model.Methods {
+		if !reflect.DeepEqual(method, expected[i]) {
+			t.Errorf("got method %d %+v, want %+v", i, method, expected[i])
+		}
+	}
+}
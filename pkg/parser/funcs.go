@@ -0,0 +1,287 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// TemplateFunction documents a function available to every template.
+type TemplateFunction struct {
+	Name  string
+	Usage string
+	Doc   string
+	fn    any
+}
+
+// templateFunctions is the function library registered on the built-in and user templates.
+// The text/template builtins (and, or, not, eq, index, len, printf, ...) are available as well.
+var templateFunctions = []TemplateFunction{
+	// Case conversion
+	{Name: "toPascalCase", Usage: "toPascalCase s", Doc: "user_service -> UserService, only the first letter of every word is kept upper case", fn: toPascalCase},
+	{Name: "toCamelCase", Usage: "toCamelCase s", Doc: "UserService -> userService", fn: toCamelCase},
+	{Name: "toSnakeCase", Usage: "toSnakeCase s", Doc: "UserService -> user_service", fn: toSnakeCase},
+	{Name: "toKebabCase", Usage: "toKebabCase s", Doc: "UserService -> user-service", fn: toKebabCase},
+	{Name: "toLower", Usage: "toLower s", Doc: "strings.ToLower", fn: strings.ToLower},
+	{Name: "toUpper", Usage: "toUpper s", Doc: "strings.ToUpper", fn: strings.ToUpper},
+	{Name: "upperFirst", Usage: "upperFirst s", Doc: "upper cases the first letter", fn: upperFirst},
+	{Name: "lowerFirst", Usage: "lowerFirst s", Doc: "lower cases the first letter", fn: lowerFirst},
+	{Name: "splitWords", Usage: "splitWords s", Doc: "splits CamelCase into words, GetUserByID -> [Get User By ID]", fn: splitCamelCase},
+
+	// Strings
+	{Name: "default", Usage: "default fallback s", Doc: "s, or fallback when s is empty, e.g. {{ .ResponseType | default \"nil\" }}", fn: defaultFunc},
+	{Name: "trimPrefix", Usage: "trimPrefix prefix s", Doc: "strings.TrimPrefix with the string last for pipelines", fn: func(prefix, s string) string { return strings.TrimPrefix(s, prefix) }},
+	{Name: "trimSuffix", Usage: "trimSuffix suffix s", Doc: "strings.TrimSuffix with the string last for pipelines", fn: func(suffix, s string) string { return strings.TrimSuffix(s, suffix) }},
+	{Name: "hasPrefix", Usage: "hasPrefix prefix s", Doc: "strings.HasPrefix with the string last for pipelines", fn: func(prefix, s string) bool { return strings.HasPrefix(s, prefix) }},
+	{Name: "hasSuffix", Usage: "hasSuffix suffix s", Doc: "strings.HasSuffix with the string last for pipelines", fn: func(suffix, s string) bool { return strings.HasSuffix(s, suffix) }},
+	{Name: "replace", Usage: "replace old new s", Doc: "strings.ReplaceAll with the string last for pipelines", fn: func(old, new, s string) string { return strings.ReplaceAll(s, old, new) }},
+	{Name: "quote", Usage: "quote s", Doc: "Go quoted string literal", fn: func(s string) string { return fmt.Sprintf("%q", s) }},
+	{Name: "rawQuote", Usage: "rawQuote s", Doc: "Go raw string literal, quoted when s can not be backquoted", fn: func(s string) string {
+		if strconv.CanBackquote(s) {
+			return "`" + s + "`"
+		}
+		return strconv.Quote(s)
+	}},
+
+	// Slices
+	{Name: "list", Usage: "list a b ...", Doc: "creates a []string", fn: func(items ...string) []string { return items }},
+	{Name: "append", Usage: "append items a b ...", Doc: "appends to a []string, {{ $args = append $args \"ctx\" }}", fn: func(items []string, item ...string) []string { return append(slices.Clone(items), item...) }},
+	{Name: "join", Usage: "join items sep", Doc: "strings.Join", fn: strings.Join},
+	{Name: "split", Usage: "split s sep", Doc: "strings.Split", fn: strings.Split},
+	{Name: "first", Usage: "first items", Doc: "first element of a []string, empty when there is none", fn: func(items []string) string {
+		if len(items) == 0 {
+			return ""
+		}
+		return items[0]
+	}},
+	{Name: "last", Usage: "last items", Doc: "last element of a []string, empty when there is none", fn: func(items []string) string {
+		if len(items) == 0 {
+			return ""
+		}
+		return items[len(items)-1]
+	}},
+	{Name: "contains", Usage: "contains items s", Doc: "reports whether a []string contains s", fn: slices.Contains[[]string]},
+
+	// Methods, params and returns
+	{Name: "hasError", Usage: "hasError .Returns", Doc: "any return is an error", fn: hasError},
+	{Name: "hasOnlyError", Usage: "hasOnlyError .Returns", Doc: "the only return is an error", fn: hasOnlyError},
+	{Name: "hasMultiple", Usage: "hasMultiple .Returns", Doc: "more than one return is not an error", fn: hasMultiple},
+	{Name: "nonErrorReturns", Usage: "nonErrorReturns .Returns", Doc: "the returns that are not errors", fn: nonErrorReturns},
+	{Name: "returnNames", Usage: "returnNames .Returns [.Params]", Doc: "variable names for the returns, result or result0, result1... and err, numbered further when they collide with .Params", fn: returnNames},
+	{Name: "paramNames", Usage: "paramNames .Params", Doc: "the names of the params", fn: paramNames},
+	{Name: "isIdempotent", Usage: "isIdempotent .", Doc: "the method can be retried, from the idempotent and nonidempotent directives or the verb its name starts with", fn: isIdempotent},
+	{Name: "hasDirective", Usage: "hasDirective . name", Doc: "the method has the //interfacery:<name> directive", fn: func(m Method, name string) bool { return slices.Contains(m.Directives, name) }},
+	{Name: "getUniqueVarName", Usage: "getUniqueVarName name [.Params]", Doc: "name, renamed when it collides with r, w, ctx or one of the params", fn: getUniqueVarName},
+
+	// Type inspection, on type strings such as "[]*users.User"
+	{Name: "isPointer", Usage: "isPointer type", Doc: "*T", fn: isPointerType},
+	{Name: "isSlice", Usage: "isSlice type", Doc: "[]T", fn: isSliceType},
+	{Name: "isMap", Usage: "isMap type", Doc: "map[K]V", fn: isMapType},
+	{Name: "isVariadic", Usage: "isVariadic type", Doc: "...T", fn: isVariadicType},
+	{Name: "isError", Usage: "isError type", Doc: "error", fn: func(t string) bool { return t == "error" }},
+	{Name: "isBasic", Usage: "isBasic type", Doc: "a predeclared type such as string, int or bool", fn: isBasicType},
+	{Name: "isNumeric", Usage: "isNumeric type", Doc: "a predeclared integer or float type", fn: isNumericType},
+	{Name: "elemType", Usage: "elemType type", Doc: "removes one level of pointer, slice, array, variadic or map value, []*T -> *T", fn: elemType},
+	{Name: "baseType", Usage: "baseType type", Doc: "removes every level of pointer, slice, array and variadic, []*T -> T", fn: baseType},
+	{Name: "zeroValue", Usage: "zeroValue type", Doc: "an expression for the zero value of the type", fn: zeroValue},
+	{Name: "typePackage", Usage: "typePackage type", Doc: "the package qualifier of the base type, users.User -> users", fn: typePackage},
+	{Name: "typeName", Usage: "typeName type", Doc: "the unqualified name of the base type, []*users.User -> User", fn: typeName},
+
+	// Import aliasing, bound to the file being rendered
+	{Name: "qualify", Usage: "qualify type", Doc: "rewrites a fully qualified type, example.com/pkg/users.User -> users.User, and imports the package"},
+	{Name: "importAlias", Usage: "importAlias path", Doc: "the identifier used for an import path, and imports the package"},
+	{Name: "protoService", Usage: "protoService .", Doc: "the protobuf service, messages and Go conversions of the interface, see ProtoService"},
+	{Name: "mcpTools", Usage: "mcpTools .", Doc: "the methods as MCP tools with JSON Schemas of their arguments and results, see MCPTool"},
+	{Name: "pythonModule", Usage: "pythonModule .", Doc: "the dataclasses, aliases and client methods of a Python client of the interface, see PythonModule"},
+	{Name: "graphqlSchema", Usage: "graphqlSchema .", Doc: "the GraphQL query and mutation fields, types and scalars of the interface, see GraphQLSchema"},
+	{Name: "fakeModel", Usage: "fakeModel .", Doc: "the resources stored by an in-memory fake of the interface and how it implements each method, see FakeModel"},
+}
+
+// TemplateFunctions lists the documented template function library.
+func TemplateFunctions() []TemplateFunction {
+	functions := slices.Clone(templateFunctions)
+	sort.SliceStable(functions, func(i, j int) bool { return functions[i].Name < functions[j].Name })
+	return functions
+}
+
+// templateFuncs returns the function library, with the import functions bound to im.
+func templateFuncs(im *importer) template.FuncMap {
+	funcs := template.FuncMap{}
+	for _, f := range templateFunctions {
+		if f.fn != nil {
+			funcs[f.Name] = f.fn
+		}
+	}
+	if im == nil {
+		im = newImporter(nil)
+	}
+	funcs["qualify"] = im.qualify
+	funcs["importAlias"] = im.alias
+	funcs["protoService"] = func(r TemplateReplace) *ProtoService { return buildProtoService(im, r) }
+	funcs["mcpTools"] = func(r TemplateReplace) []MCPTool { return buildMCPTools(im, r) }
+	funcs["pythonModule"] = func(r TemplateReplace) *PythonModule { return buildPythonModule(im, r) }
+	funcs["graphqlSchema"] = func(r TemplateReplace) *GraphQLSchema { return buildGraphQLSchema(im, r) }
+	funcs["fakeModel"] = func(r TemplateReplace) *FakeModel { return buildFakeModel(im, r) }
+	return funcs
+}
+
+func defaultFunc(fallback, value string) string {
+	return orFunc(value, fallback)
+}
+
+func toCamelCase(s string) string {
+	return lowerFirst(toPascalCase(s))
+}
+
+func toKebabCase(s string) string {
+	return strings.ReplaceAll(toSnakeCase(s), "_", "-")
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+func nonErrorReturns(returns []Return) []Return {
+	var result []Return
+	for _, r := range returns {
+		if r.Type != "error" {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// returnNames names the returns, avoiding the names of params when they are given
+func returnNames(returns []Return, params ...[]Param) []string {
+	multiple := hasMultiple(returns)
+	var names []string
+	for i, r := range returns {
+		var name string
+		switch {
+		case r.Type == "error":
+			name = "err"
+		case multiple:
+			name = fmt.Sprintf("result%d", i)
+		default:
+			name = "result"
+		}
+		if len(params) > 0 {
+			name = getUniqueVarName(name, params...)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func paramNames(params []Param) []string {
+	var names []string
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+var basicTypes = map[string]bool{
+	"bool": true, "string": true, "byte": true, "rune": true, "any": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+}
+
+func isPointerType(t string) bool  { return strings.HasPrefix(t, "*") }
+func isSliceType(t string) bool    { return strings.HasPrefix(t, "[]") }
+func isMapType(t string) bool      { return strings.HasPrefix(t, "map[") }
+func isVariadicType(t string) bool { return strings.HasPrefix(t, "...") }
+func isBasicType(t string) bool    { return basicTypes[t] }
+
+func isNumericType(t string) bool {
+	return basicTypes[t] && t != "bool" && t != "string" && t != "any" && !strings.HasPrefix(t, "complex")
+}
+
+func elemType(t string) string {
+	switch {
+	case isPointerType(t):
+		return t[1:]
+	case isSliceType(t):
+		return t[2:]
+	case isVariadicType(t):
+		return t[3:]
+	case isMapType(t):
+		// Skip the key, which may itself contain brackets
+		depth := 0
+		for i := len("map"); i < len(t); i++ {
+			switch t[i] {
+			case '[':
+				depth++
+			case ']':
+				depth--
+				if depth == 0 {
+					return t[i+1:]
+				}
+			}
+		}
+	case strings.HasPrefix(t, "["):
+		if end := strings.Index(t, "]"); end > 0 {
+			return t[end+1:]
+		}
+	}
+	return t
+}
+
+func baseType(t string) string {
+	for isPointerType(t) || isSliceType(t) || isVariadicType(t) || strings.HasPrefix(t, "[") {
+		t = elemType(t)
+	}
+	return t
+}
+
+func typePackage(t string) string {
+	t = baseType(t)
+	if dot := strings.LastIndex(t, "."); dot > 0 && !strings.ContainsAny(t, "[({") {
+		return t[:dot]
+	}
+	return ""
+}
+
+func typeName(t string) string {
+	t = baseType(t)
+	if pkg := typePackage(t); pkg != "" {
+		return t[len(pkg)+1:]
+	}
+	return t
+}
+
+func zeroValue(t string) string {
+	switch {
+	case t == "string":
+		return `""`
+	case t == "bool":
+		return "false"
+	case isNumericType(t) || t == "byte" || t == "rune" || strings.HasPrefix(t, "complex"):
+		return "0"
+	case t == "error" || t == "any" || isPointerType(t) || isSliceType(t) || isMapType(t) ||
+		strings.HasPrefix(t, "func") || strings.HasPrefix(t, "chan") || strings.HasPrefix(t, "<-chan") ||
+		strings.HasPrefix(t, "interface"):
+		return "nil"
+	default:
+		return "*new(" + t + ")"
+	}
+}
@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCaseConversion(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       func(string) string
+		input    string
+		expected string
+	}{
+		{"CamelCase", toCamelCase, "user_service", "userService"},
+		{"KebabCase", toKebabCase, "UserService", "user-service"},
+		{"UpperFirst", upperFirst, "userService", "UserService"},
+		{"LowerFirst", lowerFirst, "UserService", "userService"},
+		{"LowerFirstEmpty", lowerFirst, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.fn(tt.input)
+			if result != tt.expected {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTypeInspection(t *testing.T) {
+	tests := []struct {
+		input    string
+		elem     string
+		base     string
+		pkg      string
+		name     string
+		zero     string
+		isBasic  bool
+		isNumber bool
+	}{
+		{"string", "string", "string", "", "string", `""`, true, false},
+		{"int64", "int64", "int64", "", "int64", "0", true, true},
+		{"*users.User", "users.User", "users.User", "users", "User", "nil", false, false},
+		{"[]*users.User", "*users.User", "users.User", "users", "User", "nil", false, false},
+		{"map[string][]int", "[]int", "map[string][]int", "", "map[string][]int", "nil", false, false},
+		{"...string", "string", "string", "", "string", "*new(...string)", false, false},
+		{"users.User", "users.User", "users.User", "users", "User", "*new(users.User)", false, false},
+		{"error", "error", "error", "", "error", "nil", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := elemType(tt.input); got != tt.elem {
+				t.Errorf("elemType got %v, want %v", got, tt.elem)
+			}
+			if got := baseType(tt.input); got != tt.base {
+				t.Errorf("baseType got %v, want %v", got, tt.base)
+			}
+			if got := typePackage(tt.input); got != tt.pkg {
+				t.Errorf("typePackage got %v, want %v", got, tt.pkg)
+			}
+			if got := typeName(tt.input); got != tt.name {
+				t.Errorf("typeName got %v, want %v", got, tt.name)
+			}
+			if got := zeroValue(tt.input); got != tt.zero {
+				t.Errorf("zeroValue got %v, want %v", got, tt.zero)
+			}
+			if got := isBasicType(tt.input); got != tt.isBasic {
+				t.Errorf("isBasic got %v, want %v", got, tt.isBasic)
+			}
+			if got := isNumericType(tt.input); got != tt.isNumber {
+				t.Errorf("isNumeric got %v, want %v", got, tt.isNumber)
+			}
+		})
+	}
+}
+
+func TestReturnNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		returns  []Return
+		params   []Param
+		expected string
+	}{
+		{"ResultAndError", mockReturns1, nil, "result,err"},
+		{"OnlyError", mockReturns2, nil, "err"},
+		{"Multiple", mockReturns3, nil, "result0,result1"},
+		{"ParamCollisions", mockReturns1, []Param{{Name: "result", Type: "int"}, {Name: "err", Type: "string"}}, "result1,err1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := strings.Join(returnNames(tt.returns, tt.params), ",")
+			if result != tt.expected {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestImporterQualify(t *testing.T) {
+	im := newImporter(map[string]string{
+		"example.com/fx/pkg/users": "users",
+		"example.com/other/users":  "users",
+		"github.com/x/go-yaml/v3":  "yaml",
+	})
+	im.reserve("example.com/fx/pkg/users", "users")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"string", "string"},
+		{"context.Context", "context.Context"},
+		{"[]*example.com/fx/pkg/users.User", "[]*users.User"},
+		{"map[string]example.com/other/users.User", "map[string]users1.User"},
+		{"...github.com/x/go-yaml/v3.Node", "...yaml.Node"},
+		{"users.User", "users.User"},
+		{"func(example.com/unknown/v2.T) error", "func(unknown.T) error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := im.qualify(tt.input)
+			if result != tt.expected {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+
+	paths, aliases := im.imports()
+	if paths["example.com/fx/pkg/users"] {
+		t.Error("reserved package should not be imported")
+	}
+	if aliases["example.com/other/users"] != "users1" {
+		t.Errorf("got alias %q, want users1", aliases["example.com/other/users"])
+	}
+	if _, ok := aliases["context"]; ok {
+		t.Error("context should not need an alias")
+	}
+}
+
+func TestBuiltinTemplatesRender(t *testing.T) {
+	for _, builtin := range BuiltinTemplates() {
+		t.Run(builtin.Kind, func(t *testing.T) {
+			if out, err := ValidateTemplate(builtin.Kind, builtin.Source, nil); err != nil {
+				t.Errorf("%v\n%s", err, out)
+			}
+		})
+	}
+}
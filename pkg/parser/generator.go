@@ -0,0 +1,265 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+)
+
+// Generator renders targets in-process. It is what the generate command runs,
+// without reading flags or a config file.
+type Generator struct {
+	targets   []Target
+	target    Target // Used when no targets are given
+	templates map[string]templateSource
+	output    OutputFS
+	dryRun    bool
+}
+
+type templateSource struct {
+	source   string
+	partials map[string]string
+}
+
+// Option configures a Generator.
+type Option func(*Generator)
+
+// NewGenerator returns a Generator. Without WithTargets, it renders a single target
+// built from WithSources, WithSelection, WithKind and WithOutputDir.
+func NewGenerator(opts ...Option) *Generator {
+	g := &Generator{
+		target:    Target{Name: "default"},
+		templates: map[string]templateSource{},
+		output:    OSFS{},
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// WithTargets adds targets to the generator, see LoadConfig to read them from a project config.
+func WithTargets(targets ...Target) Option {
+	return func(g *Generator) { g.targets = append(g.targets, targets...) }
+}
+
+// WithSources sets the directories searched for interfaces, defaults to the working directory.
+func WithSources(dirs ...string) Option {
+	return func(g *Generator) { g.target.Sources = dirs }
+}
+
+// WithSelection sets the interfaces that are rendered.
+func WithSelection(sel Selection) Option {
+	return func(g *Generator) {
+		g.target.Interfaces = sel.Include
+		g.target.Exclude = sel.Exclude
+		g.target.Marked = sel.Marked
+		g.target.Unexported = sel.Unexported
+		g.target.Tests = sel.Tests
+		g.target.Tags = sel.BuildTags
+	}
+}
+
+// WithKind sets the generator kind, defaults to "handler".
+func WithKind(kind string) Option {
+	return func(g *Generator) { g.target.Kind = kind }
+}
+
+// WithOutputDir sets the directory the files are rendered into.
+func WithOutputDir(dir string) Option {
+	return func(g *Generator) { g.target.Output = dir }
+}
+
+// WithRoutePrefix sets the prefix of every inferred URL path.
+func WithRoutePrefix(prefix string) Option {
+	return func(g *Generator) { g.target.RoutePrefix = prefix }
+}
+
+// WithPathParams binds the params named after the By of a method name, such as id in
+// GetUserByID, to path segments of its route instead of the query.
+func WithPathParams(enabled bool) Option {
+	return func(g *Generator) { g.target.PathParams = enabled }
+}
+
+// WithTemplate overrides the built-in template of kind for every target that does not set its own.
+func WithTemplate(kind, source string, partials map[string]string) Option {
+	return func(g *Generator) { g.templates[kind] = templateSource{source: source, partials: partials} }
+}
+
+// WithOutputFS sets where the files are compared with and written to, defaults to OSFS.
+func WithOutputFS(fsys OutputFS) Option {
+	return func(g *Generator) { g.output = fsys }
+}
+
+// WithDryRun renders and compares the files without writing them.
+func WithDryRun(dryRun bool) Option {
+	return func(g *Generator) { g.dryRun = dryRun }
+}
+
+// FileStatus tells how a rendered file compares with the OutputFS.
+type FileStatus string
+
+const (
+	FileCreated   FileStatus = "created"
+	FileUpdated   FileStatus = "updated"
+	FileUnchanged FileStatus = "unchanged"
+)
+
+// FileResult is a file rendered by a Generator.
+type FileResult struct {
+	Target    string     `json:"target"`
+	Interface string     `json:"interface"`
+	Path      string     `json:"path"`
+	Status    FileStatus `json:"status"`
+	Content   []byte     `json:"-"`
+}
+
+// Result is the outcome of Generator.Generate.
+type Result struct {
+	Files       []FileResult `json:"files"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Changed returns the files that were created or updated.
+func (r *Result) Changed() []FileResult {
+	var changed []FileResult
+	for _, f := range r.Files {
+		if f.Status != FileUnchanged {
+			changed = append(changed, f)
+		}
+	}
+	return changed
+}
+
+// HasErrors reports whether any diagnostic is an error.
+func (r *Result) HasErrors() bool {
+	return slices.ContainsFunc(r.Diagnostics, func(d Diagnostic) bool { return d.Severity == SeverityError })
+}
+
+// Targets returns the targets the generator renders, with their defaults filled in.
+func (g *Generator) Targets() ([]Target, error) {
+	targets := slices.Clone(g.targets)
+	if len(targets) == 0 {
+		targets = []Target{g.target}
+	}
+	for i := range targets {
+		if err := targets[i].setDefaults(i); err != nil {
+			return nil, err
+		}
+	}
+	return targets, nil
+}
+
+// Generate renders every target and, unless WithDryRun is set, writes the files that changed.
+// The returned Result holds the files and diagnostics found so far, even when an error is returned.
+func (g *Generator) Generate(ctx context.Context) (*Result, error) {
+	result := &Result{}
+	diags := &diagnostics{}
+	defer func() { result.Diagnostics = diags.list }()
+
+	targets, err := g.Targets()
+	if err != nil {
+		diags.add(Diagnostic{Severity: SeverityError, Message: err.Error()})
+		return result, err
+	}
+
+	jobs, jobTargets, err := g.jobs(targets, diags)
+	if err != nil {
+		return result, err
+	}
+
+	perJob, err := renderJobs(ctx, jobs, diags)
+	if err != nil {
+		diags.add(Diagnostic{Severity: SeverityError, Message: err.Error()})
+		return result, err
+	}
+
+	for j, files := range perJob {
+		for _, f := range files {
+			status, err := g.status(f)
+			if err != nil {
+				return result, err
+			}
+			if status != FileUnchanged && !g.dryRun {
+				if err := g.output.WriteFile(f.Path, f.Content); err != nil {
+					return result, fmt.Errorf("failed to write %s: %w", f.Path, err)
+				}
+			}
+			result.Files = append(result.Files, FileResult{
+				Target:    jobTargets[j],
+				Interface: f.Interface,
+				Path:      f.Path,
+				Status:    status,
+				Content:   f.Content,
+			})
+		}
+	}
+	return result, nil
+}
+
+// jobs returns the render jobs of every target along with the target name of each job.
+func (g *Generator) jobs(targets []Target, diags *diagnostics) ([]RenderJob, []string, error) {
+	var jobs []RenderJob
+	var jobTargets []string
+	for _, t := range targets {
+		targetJobs, err := targetRenderJobs(t, nil)
+		if err != nil {
+			diags.add(Diagnostic{Severity: SeverityError, Target: t.Name, Message: err.Error()})
+			return nil, nil, err
+		}
+		for _, job := range targetJobs {
+			if override, ok := g.templates[t.Kind]; ok && t.Template == "" {
+				job.Options.Template, job.Options.Partials = override.source, override.partials
+			}
+			jobs = append(jobs, job)
+			jobTargets = append(jobTargets, t.Name)
+		}
+	}
+	return jobs, jobTargets, nil
+}
+
+// Model is the resolved model of the selected interfaces, see Generator.Inspect.
+type Model struct {
+	Interfaces  []Interface  `json:"interfaces" yaml:"interfaces"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty" yaml:"diagnostics,omitempty"`
+}
+
+// Inspect resolves the model of every interface the generator would render, without rendering it.
+func (g *Generator) Inspect(ctx context.Context) (*Model, error) {
+	model := &Model{}
+	diags := &diagnostics{}
+	defer func() { model.Diagnostics = diags.list }()
+
+	targets, err := g.Targets()
+	if err != nil {
+		diags.add(Diagnostic{Severity: SeverityError, Message: err.Error()})
+		return model, err
+	}
+	jobs, jobTargets, err := g.jobs(targets, diags)
+	if err != nil {
+		return model, err
+	}
+	model.Interfaces, err = loadInterfaces(ctx, jobs, jobTargets, diags)
+	if err != nil {
+		diags.add(Diagnostic{Severity: SeverityError, Message: err.Error()})
+		return model, err
+	}
+	return model, nil
+}
+
+func (g *Generator) status(f GeneratedFile) (FileStatus, error) {
+	current, err := g.output.ReadFile(f.Path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return FileCreated, nil
+	case err != nil:
+		return "", fmt.Errorf("failed to read %s: %w", f.Path, err)
+	case bytes.Equal(current, f.Content):
+		return FileUnchanged, nil
+	default:
+		return FileUpdated, nil
+	}
+}
@@ -0,0 +1,756 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerator(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\ntype UserService interface {\n\tGetUser(id string) (string, error)\n}\n\ntype Counter interface {\n\tCount() int\n}\n")
+	out := filepath.Join(root, "out")
+	userFile := filepath.Join(out, "user_service_handlers.go")
+
+	fsys := NewMemFS(nil)
+	opts := []Option{WithSources(root), WithSelection(Selection{Include: []string{"*Service"}}), WithOutputDir(out), WithOutputFS(fsys)}
+
+	// Dry runs report the files without writing them
+	result, err := NewGenerator(append(opts, WithDryRun(true))...).Generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 1 || result.Files[0].Status != FileCreated || result.Files[0].Interface != "UserService" || result.Files[0].Target != "default" {
+		t.Fatalf("got %+v, want a created UserService file", result.Files)
+	}
+	if len(fsys.Files()) != 0 {
+		t.Errorf("dry run wrote %d files", len(fsys.Files()))
+	}
+
+	if _, err := NewGenerator(opts...).Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.ReadFile(userFile); err != nil {
+		t.Fatal(err)
+	}
+	result, err = NewGenerator(opts...).Generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changed()) != 0 {
+		t.Errorf("got %+v, want no changes", result.Changed())
+	}
+
+	// Template overrides apply to targets without their own template
+	result, err = NewGenerator(append(opts, WithTemplate("handler", "package {{.DirPackageName}}\n\n// {{.InterfaceName}}\n", nil))...).Generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := fsys.ReadFile(userFile)
+	if len(result.Files) != 1 || result.Files[0].Status != FileUpdated || string(content) != "package out\n\n// UserService\n" {
+		t.Errorf("got %+v with %q, want the override to be written", result.Files, content)
+	}
+}
+
+func TestGeneratorDiagnostics(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "broken.go"), "package root\n\ntype Broken interface {\n\tGet() Missing\n}\n")
+
+	result, err := NewGenerator(WithSources(root), WithOutputDir(filepath.Join(root, "out")), WithOutputFS(NewMemFS(nil))).Generate(context.Background())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !result.HasErrors() {
+		t.Fatalf("got %+v, want error diagnostics", result.Diagnostics)
+	}
+	if d := result.Diagnostics[0]; d.Pos == "" || d.Message == "" {
+		t.Errorf("got %+v, want a positioned diagnostic", d)
+	}
+
+	if _, err := NewGenerator(WithSources(root)).Generate(context.Background()); err == nil {
+		t.Error("expected an error for a target without output")
+	}
+}
+
+func TestGeneratorInspect(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), `package users
+
+import "context"
+
+type Base struct {
+	ID string `+"`json:\"id\"`"+`
+}
+
+type User struct {
+	Base
+	Name    string `+"`json:\"name,omitempty\"`"+`
+	Friends []*User
+	secret  string
+	Skipped string `+"`json:\"-\"`"+`
+}
+
+// UserService manages users.
+type UserService interface {
+	// GetUser returns a single user.
+	GetUser(ctx context.Context, id string) (*User, error)
+	//interfacery:idempotent
+	Split(names ...string) (first, rest string, err error)
+}
+`)
+
+	model, err := NewGenerator(WithSources(root), WithOutputDir(filepath.Join(root, "out"))).Inspect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Interfaces) != 1 {
+		t.Fatalf("got %d interfaces, want 1", len(model.Interfaces))
+	}
+	i := model.Interfaces[0]
+	if i.Doc != "UserService manages users." || i.ImportPath != "example.com/root/users" || i.Target != "default" {
+		t.Errorf("got %+v", i)
+	}
+
+	get := i.Methods[0]
+	if get.Doc != "GetUser returns a single user." || !get.HasContext || get.URLPath != "/userservice/user" || get.Params[0].Binding != "query" || !reflect.DeepEqual(get.QueryParams, []string{"id"}) {
+		t.Errorf("got %+v, want id bound to the query", get)
+	}
+	if r := get.Returns[0]; !r.IsPointer || r.Package != "example.com/root/users" {
+		t.Errorf("got %+v, want a pointer into example.com/root/users", r)
+	}
+	split := i.Methods[1]
+	if len(split.Returns) != 3 || !split.Params[0].IsElipse || !reflect.DeepEqual(split.Directives, []string{"idempotent"}) {
+		t.Errorf("got %+v, want three returns, a variadic param and the idempotent directive", split)
+	}
+
+	expected := []TypeDef{{
+		Name:    "example.com/root/users.Base",
+		Package: "example.com/root/users",
+		Kind:    "struct",
+		Fields:  []Field{{Name: "ID", Type: "string", JSONName: "id"}},
+	}, {
+		Name:    "example.com/root/users.User",
+		Package: "example.com/root/users",
+		Kind:    "struct",
+		Fields: []Field{
+			{Name: "ID", Type: "string", JSONName: "id"},
+			{Name: "Name", Type: "string", JSONName: "name", OmitEmpty: true},
+			{Name: "Friends", Type: "[]*example.com/root/users.User", JSONName: "Friends"},
+		},
+	}}
+	if !reflect.DeepEqual(i.Types, expected) {
+		t.Errorf("got %+v, want %+v", i.Types, expected)
+	}
+}
+
+func TestGeneratorPathParams(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\ntype UserService interface {\n\tGetUserByID(id string, fields string) (string, error)\n}\n")
+	out := filepath.Join(root, "out")
+
+	tests := []struct {
+		name       string
+		pathParams bool
+		urlPath    string
+		handler    []string
+		client     string
+	}{
+		{"query", false, "/userservice/user", []string{`idStr := r.URL.Query().Get("id")`}, `query.Set("id", id)`},
+		{"path", true, "/userservice/user/{id}", []string{`"github.com/gorilla/mux"`, `idStr := mux.Vars(r)["id"]`}, `urlPath = strings.Replace(urlPath, "{id}", url.PathEscape(id), 1)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithSources(root), WithOutputDir(out), WithPathParams(tt.pathParams)}
+			model, err := NewGenerator(opts...).Inspect(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m := model.Interfaces[0].Methods[0]; m.URLPath != tt.urlPath || m.Params[1].Binding != "query" {
+				t.Errorf("got %+v, want %s with fields bound to the query", m, tt.urlPath)
+			}
+
+			fsys := NewMemFS(nil)
+			for _, kind := range []string{"handler", "client"} {
+				if _, err := NewGenerator(append(opts, WithKind(kind), WithOutputFS(fsys))...).Generate(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			handler, _ := fsys.ReadFile(filepath.Join(out, "user_service_handlers.go"))
+			for _, want := range tt.handler {
+				if !strings.Contains(string(handler), want) {
+					t.Errorf("got %s, want it to contain %s", handler, want)
+				}
+			}
+			if !tt.pathParams && strings.Contains(string(handler), "gorilla/mux") {
+				t.Errorf("got %s, want no mux import without path params", handler)
+			}
+			client, _ := fsys.ReadFile(filepath.Join(out, "user_service_client.go"))
+			if !strings.Contains(string(client), tt.client) {
+				t.Errorf("got %s, want it to contain %s", client, tt.client)
+			}
+		})
+	}
+}
+
+func TestGeneratorConnect(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\ntype UserService interface {\n\tRecalculateBalances(limit int) error\n}\n")
+	out := filepath.Join(root, "out")
+
+	fsys := NewMemFS(nil)
+	targets := []Target{
+		{Name: "server", Kind: "connect", Sources: []string{root}, Output: out, Options: map[string]string{"proto_package": "app.v1"}},
+		{Name: "client", Kind: "connect-client", Sources: []string{root}, Output: out, Options: map[string]string{"proto_package": "app.v1"}},
+	}
+	result, err := NewGenerator(WithTargets(targets...), WithOutputFS(fsys)).Generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 2 || len(result.Diagnostics) != 0 {
+		t.Fatalf("got %+v, want a server and a client without diagnostics", result)
+	}
+	server, _ := fsys.ReadFile(filepath.Join(out, "user_service_connect.go"))
+	if !strings.Contains(string(server), `const UserServiceConnectPath = "/app.v1.UserService/"`) {
+		t.Errorf("got %s, want the procedures under the proto package", server)
+	}
+	client, _ := fsys.ReadFile(filepath.Join(out, "user_service_connect_client.go"))
+	if !strings.Contains(string(client), `c.BaseURL+"/app.v1.UserService/"+method`) {
+		t.Errorf("got %s, want the client to call the procedures under the proto package", client)
+	}
+}
+
+func TestGeneratorJSONRPC(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\ntype UserService interface {\n\tGetName(id string) (string, error)\n\tPing()\n}\n")
+	out := filepath.Join(root, "out")
+
+	fsys := NewMemFS(nil)
+	targets := []Target{
+		{Name: "server", Kind: "jsonrpc", Sources: []string{root}, Output: out},
+		{Name: "client", Kind: "jsonrpc-client", Sources: []string{root}, Output: out},
+	}
+	result, err := NewGenerator(WithTargets(targets...), WithOutputFS(fsys)).Generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 2 || len(result.Diagnostics) != 0 {
+		t.Fatalf("got %+v, want a server and a client without diagnostics", result)
+	}
+	server, _ := fsys.ReadFile(filepath.Join(out, "user_service_jsonrpc.go"))
+	if !strings.Contains(string(server), `case "UserService.GetName":`) {
+		t.Errorf("got %s, want the methods named after the interface", server)
+	}
+	client, _ := fsys.ReadFile(filepath.Join(out, "user_service_jsonrpc_client.go"))
+	if !strings.Contains(string(client), `c.call(context.Background(), "Ping", nil, true)`) {
+		t.Errorf("got %s, want Ping sent as a notification", client)
+	}
+}
+
+func TestGeneratorCLI(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\ntype Filter struct{ Name string }\n\ntype UserService interface {\n\tSearchUsers(limit int, filter Filter) ([]string, error)\n}\n")
+	out := filepath.Join(root, "out")
+
+	fsys := NewMemFS(nil)
+	targets := []Target{
+		{Name: "cli", Kind: "cli", Sources: []string{root}, Output: out, Options: map[string]string{"client_package": "example.com/root/client"}},
+	}
+	if _, err := NewGenerator(WithTargets(targets...), WithOutputFS(fsys)).Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := fsys.ReadFile(filepath.Join(out, "user_service_cli.go"))
+	for _, want := range []string{
+		`Use:   "search-users"`,
+		`cmd.Flags().IntVar(&flags.Limit, "limit", 0, "limit")`,
+		`userServiceCLIDecode(cmd, "filter", flags.Filter, &in.Filter)`,
+		`return client.NewUserserviceClient(c.url, nil), nil`,
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("got %s, want it to contain %s", content, want)
+		}
+	}
+}
+
+func TestGeneratorRecorder(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\ntype UserService interface {\n\tGetName(id string, tags ...string) (string, error)\n\tPing()\n}\n")
+	out := filepath.Join(root, "out")
+
+	fsys := NewMemFS(nil)
+	targets := []Target{{Name: "recorder", Kind: "recorder", Sources: []string{root}, Output: out}}
+	if _, err := NewGenerator(WithTargets(targets...), WithOutputFS(fsys)).Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := fsys.ReadFile(filepath.Join(out, "user_service_recorder.go"))
+	for _, want := range []string{
+		`rec.record("GetName", userServiceGetNameArgs{id, tags}, userServiceGetNameResults{result}, err)`,
+		`call, err := userServiceReplay(rp, "GetName", userServiceGetNameArgs{id, tags})`,
+		`if _, err := userServiceReplay(rp, "Ping", struct{}{}); err != nil {`,
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("got %s, want it to contain %s", content, want)
+		}
+	}
+}
+
+// recorderRuntimeTest records calls and replays them, matching sentinel errors
+const recorderRuntimeTest = `package out
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"example.com/root/users"
+)
+
+type impl struct{ calls int }
+
+func (s *impl) GetName(id string, tags ...string) (string, error) {
+	s.calls++
+	switch id {
+	case "missing":
+		return "", users.ErrNotFound
+	case "wrapped":
+		return "", fmt.Errorf("user %s: %w", id, users.ErrNotFound)
+	}
+	return fmt.Sprint(id, s.calls), nil
+}
+
+func (s *impl) Ping() {}
+
+func TestReplay(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewUserServiceRecorder(&impl{}, &buf)
+	rec.GetName("a")
+	rec.GetName("a")
+	rec.GetName("a", "admin")
+	rec.GetName("missing")
+	rec.GetName("wrapped")
+	rec.Ping()
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	rp, err := NewUserServiceReplayer(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp.RegisterErrors(users.ErrNotFound)
+	// Equal calls are answered in order, then with the last recording
+	for _, want := range []string{"a1", "a2", "a2"} {
+		if got, err := rp.GetName("a"); got != want || err != nil {
+			t.Errorf("got %q, %v, want %q", got, err, want)
+		}
+	}
+	if got, err := rp.GetName("a", "admin"); got != "a3" || err != nil {
+		t.Errorf("got %q, %v with tags, want a3", got, err)
+	}
+	if _, err := rp.GetName("a", "other"); !errors.Is(err, ErrUserServiceUnmatched) {
+		t.Errorf("got %v, want ErrUserServiceUnmatched", err)
+	}
+	if _, err := rp.GetName("missing"); err != users.ErrNotFound {
+		t.Errorf("got %v, want users.ErrNotFound", err)
+	}
+	if _, err := rp.GetName("wrapped"); !errors.Is(err, users.ErrNotFound) || err.Error() != "user wrapped: not found" {
+		t.Errorf("got %v, want it to wrap users.ErrNotFound", err)
+	}
+	if unused := rp.Unused(); len(unused) != 1 || unused[0].Method != "Ping" {
+		t.Errorf("got unused %+v, want Ping", unused)
+	}
+	rp.Ping()
+	if unused := rp.Unused(); len(unused) != 0 {
+		t.Errorf("got unused %+v, want none", unused)
+	}
+}
+
+func TestReplayUnregisteredError(t *testing.T) {
+	var buf bytes.Buffer
+	NewUserServiceRecorder(&impl{}, &buf).GetName("missing")
+	rp, err := NewUserServiceReplayer(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rp.GetName("missing"); err == nil || errors.Is(err, users.ErrNotFound) || err.Error() != "not found" {
+		t.Errorf("got %v, want only the message of users.ErrNotFound", err)
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("got no panic replaying an unrecorded Ping")
+		}
+	}()
+	rp.Ping()
+}
+`
+
+func TestGeneratorRecorderRuntime(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\nimport \"errors\"\n\nvar ErrNotFound = errors.New(\"not found\")\n\ntype UserService interface {\n\tGetName(id string, tags ...string) (string, error)\n\tPing()\n}\n")
+	out := filepath.Join(root, "out")
+
+	targets := []Target{{Name: "recorder", Kind: "recorder", Sources: []string{filepath.Join(root, "users")}, Output: out}}
+	if _, err := NewGenerator(WithTargets(targets...)).Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(out, "recorder_test.go"), recorderRuntimeTest)
+	goTest(t, root)
+}
+
+func TestGeneratorResilience(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\ntype UserService interface {\n\tGetName(id string) (string, error)\n\tSendEmail(to string) error\n\tPing()\n}\n")
+	out := filepath.Join(root, "out")
+
+	fsys := NewMemFS(nil)
+	targets := []Target{{Name: "resilience", Kind: "resilience", Sources: []string{root}, Output: out}}
+	if _, err := NewGenerator(WithTargets(targets...), WithOutputFS(fsys)).Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := fsys.ReadFile(filepath.Join(out, "user_service_resilience.go"))
+	for _, want := range []string{
+		`"GetName":   true,`,
+		`"SendEmail": false,`,
+		`err = c.call(context.Background(), "SendEmail", func() error {`,
+		`c.next.Ping()`,
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("got %s, want it to contain %s", content, want)
+		}
+	}
+}
+
+// goTest runs the tests of the module in dir, which compile the generated code with the go tool
+func goTest(t *testing.T, dir string) {
+	t.Helper()
+	goCommand(t, dir, "test", "./...")
+}
+
+// goCommand runs the go tool in the module at dir, outside of any workspace. The modules of the
+// tests only require their direct dependencies, -mod=mod lets the go tool add the others.
+func goCommand(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("the go tool is not installed")
+	}
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go %s: %v\n%s", args[0], err, out)
+	}
+}
+
+// resilienceRuntimeTest exercises the retries and circuit breaker of the generated wrapper
+const resilienceRuntimeTest = `package out
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errFlaky = errors.New("flaky")
+
+type flaky struct {
+	calls int
+	fails int
+}
+
+func (f *flaky) GetName(id string) (string, error) {
+	f.calls++
+	if f.calls <= f.fails {
+		return "", errFlaky
+	}
+	return "name " + id, nil
+}
+
+func (f *flaky) SendEmail(to string) error {
+	f.calls++
+	return errFlaky
+}
+
+func (f *flaky) Ping() {}
+
+func TestRetry(t *testing.T) {
+	impl := &flaky{fails: 2}
+	c := NewUserServiceResilient(impl, UserServiceResilienceOptions{Retry: UserServiceRetryPolicy{Attempts: 3, Backoff: time.Millisecond}})
+	if name, err := c.GetName("a"); err != nil || name != "name a" || impl.calls != 3 {
+		t.Errorf("got %q, %v after %d calls, want the third call to succeed", name, err, impl.calls)
+	}
+	// Methods that are not idempotent are called once
+	impl.calls = 0
+	if err := c.SendEmail("a"); !errors.Is(err, errFlaky) || impl.calls != 1 {
+		t.Errorf("got %v after %d calls, want a single call", err, impl.calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := UserServiceRetryPolicy{Backoff: 10 * time.Millisecond, MaxBackoff: 25 * time.Millisecond}
+	for attempt, want := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 3: 25 * time.Millisecond, 10: 25 * time.Millisecond} {
+		if got := userServiceBackoff(policy, attempt); got != want {
+			t.Errorf("got %v after attempt %d, want %v", got, attempt, want)
+		}
+	}
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := userServiceBackoff(policy, 1); got < 5*time.Millisecond || got > 15*time.Millisecond {
+			t.Fatalf("got %v, want a wait within 50%% of 10ms", got)
+		}
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	impl := &flaky{fails: 100}
+	c := NewUserServiceResilient(impl, UserServiceResilienceOptions{
+		Retry:   UserServiceRetryPolicy{Attempts: 5, Backoff: time.Millisecond},
+		Breaker: UserServiceBreaker{Failures: 2, Cooldown: 50 * time.Millisecond},
+	})
+	// The circuit opens during the retries, the error tells why along with the last failure
+	_, err := c.GetName("a")
+	if !errors.Is(err, ErrUserServiceCircuitOpen) || !errors.Is(err, errFlaky) || impl.calls != 2 {
+		t.Fatalf("got %v after %d calls, want the circuit to open after 2", err, impl.calls)
+	}
+	if _, err := c.GetName("a"); !errors.Is(err, ErrUserServiceCircuitOpen) || errors.Is(err, errFlaky) || impl.calls != 2 {
+		t.Fatalf("got %v after %d calls, want to fail fast", err, impl.calls)
+	}
+
+	// A successful trial call closes the circuit once it cooled down
+	time.Sleep(60 * time.Millisecond)
+	impl.calls, impl.fails = 0, 0
+	if _, err := c.GetName("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetName("b"); err != nil || impl.calls != 2 {
+		t.Fatalf("got %v after %d calls, want the circuit closed", err, impl.calls)
+	}
+}
+`
+
+func TestGeneratorResilienceRuntime(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\ntype UserService interface {\n\tGetName(id string) (string, error)\n\tSendEmail(to string) error\n\tPing()\n}\n")
+	out := filepath.Join(root, "out")
+
+	targets := []Target{{Name: "resilience", Kind: "resilience", Sources: []string{filepath.Join(root, "users")}, Output: out}}
+	if _, err := NewGenerator(WithTargets(targets...)).Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(out, "resilience_test.go"), resilienceRuntimeTest)
+	goTest(t, root)
+}
+
+// goVet vets the package pattern of the module in dir
+func goVet(t *testing.T, dir, pattern string) {
+	t.Helper()
+	goCommand(t, dir, "vet", pattern)
+}
+
+// awkwardInterface names its params like the receivers and locals of the generated code
+const awkwardInterface = `package things
+
+import (
+	"context"
+	"time"
+)
+
+type Thing struct {
+	ID   string
+	Name string
+}
+
+type Filter struct {
+	Name string
+}
+
+type Things interface {
+	GetThing(ctx context.Context, c string) (*Thing, error)
+	CreateThing(ctx context.Context, req *Thing) (*Thing, error)
+	UpdateThing(ctx context.Context, f *Thing, err string) error
+	ListThings(ctx context.Context, call string, result int) ([]*Thing, int, error)
+	Filtered(ctx context.Context, f *Filter, since time.Time) ([]Thing, error)
+	Send(resp string, params []string, rec int, rp bool, results string, stored bool) (string, error)
+	Count(x int) int
+	DeleteThingByID(ctx context.Context, id string) error
+}
+`
+
+// writeTestModule writes the go.mod of the example.com/root module requiring the modules the generated
+// code imports, such as cobra for the CLI, with the checksums of this module
+func writeTestModule(t *testing.T, root string, requires ...string) {
+	t.Helper()
+	goSum, err := os.ReadFile(filepath.Join("..", "..", "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n\nrequire (\n\t"+strings.Join(requires, "\n\t")+"\n)\n")
+	writeTestFile(t, filepath.Join(root, "go.sum"), string(goSum))
+}
+
+func TestGeneratorParamNameCollisions(t *testing.T) {
+	root := t.TempDir()
+	writeTestModule(t, root,
+		"github.com/Seann-Moser/go-serve v0.9.56",
+		"github.com/gorilla/mux v1.8.1",
+		"github.com/spf13/cobra v1.8.1",
+		"google.golang.org/grpc v1.71.0",
+		"google.golang.org/protobuf v1.36.9",
+	)
+	writeTestFile(t, filepath.Join(root, "things", "things.go"), awkwardInterface)
+
+	// The handler and client are also rendered with the params bound to the path
+	tests := []struct {
+		kind       string
+		dir        string
+		pathParams bool
+	}{
+		{"handler", "handler", false},
+		{"handler", "handlerpath", true},
+		{"client", "client", false},
+		{"client", "clientpath", true},
+		{"grpc", "grpc", false},
+		{"connect", "connect", false},
+		{"connect-client", "connectclient", false},
+		{"jsonrpc", "jsonrpc", false},
+		{"jsonrpc-client", "jsonrpcclient", false},
+		{"mcp", "mcp", false},
+		{"cli", "cli", false},
+		{"graphql-resolvers", "graphqlresolvers", false},
+		{"fake", "fake", false},
+		{"recorder", "recorder", false},
+		{"resilience", "resilience", false},
+	}
+	sources := []string{filepath.Join(root, "things")}
+	// The gRPC adapters use the code protoc generates from the proto kind
+	targets := []Target{{Name: "proto", Kind: "proto", Sources: sources, Output: filepath.Join(root, "out", "grpc")}}
+	for _, tt := range tests {
+		targets = append(targets, Target{Name: tt.dir, Kind: tt.kind, Sources: sources, Output: filepath.Join(root, "out", tt.dir), PathParams: tt.pathParams})
+	}
+	result, err := NewGenerator(WithTargets(targets...)).Generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != len(targets) || len(result.Diagnostics) != 0 {
+		t.Fatalf("got %+v, want a file per target without diagnostics", result)
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			if tt.kind == "grpc" {
+				protoc(t, root, filepath.Join("out", "grpc", "things.proto"))
+			}
+			goVet(t, root, "./out/"+tt.dir)
+		})
+	}
+}
+
+// protoc generates the Go messages and gRPC services of the proto files into the example.com/root module at root.
+func protoc(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, tool := range []string{"protoc", "protoc-gen-go", "protoc-gen-go-grpc"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s is not installed", tool)
+		}
+	}
+	args := []string{"-I", ".", "--go_out=.", "--go_opt=module=example.com/root", "--go-grpc_out=.", "--go-grpc_opt=module=example.com/root"}
+	cmd := exec.Command("protoc", append(args, files...)...)
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("protoc: %v\n%s", err, out)
+	}
+}
+
+// cliRuntimeTest runs the generated command, decoding the JSON flags from values, files and stdin
+const cliRuntimeTest = `package out
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"example.com/root/users"
+)
+
+type impl struct{}
+
+func (impl) SearchUsers(limit int, filter users.Filter, role users.Role) ([]string, error) {
+	return []string{fmt.Sprint(limit, " ", filter.Name, " ", role)}, nil
+}
+
+func run(stdin string, args ...string) (string, error) {
+	cmd := NewUserServiceCommand(impl{})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestDecode(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "filter.json")
+	if err := os.WriteFile(file, []byte(` + "`" + `{"name": "file"}` + "`" + `), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		stdin    string
+		args     []string
+		expected string
+	}{
+		{"", []string{"--limit", "2", "--filter", ` + "`" + `{"name": "flag"}` + "`" + `, "--role", ` + "`" + `"admin"` + "`" + `}, "2 flag admin"},
+		// Strings that are not JSON are decoded as JSON strings
+		{"", []string{"--limit", "0", "--filter", "{}", "--role", "admin"}, "0  admin"},
+		{"", []string{"--limit", "0", "--filter", "@" + file, "--role", "admin"}, "0 file admin"},
+		{` + "`" + `{"name": "stdin"}` + "`" + `, []string{"--limit", "0", "--filter", "-", "--role", "admin"}, "0 stdin admin"},
+	}
+	for _, tt := range tests {
+		out, err := run(tt.stdin, append([]string{"search-users"}, tt.args...)...)
+		if err != nil || out != "[\n  \"" + tt.expected + "\"\n]\n" {
+			t.Errorf("got %q, %v with %v, want %q", out, err, tt.args, tt.expected)
+		}
+	}
+
+	if _, err := run("", "search-users", "--limit", "0", "--filter", "{", "--role", "admin"); err == nil || !strings.HasPrefix(err.Error(), "--filter: ") {
+		t.Errorf("got %v, want the invalid JSON reported for --filter", err)
+	}
+	if _, err := run("", "search-users", "--limit", "0", "--role", "admin"); err == nil || !strings.Contains(err.Error(), "filter") {
+		t.Errorf("got %v, want --filter required", err)
+	}
+}
+`
+
+func TestGeneratorCLIRuntime(t *testing.T) {
+	root := t.TempDir()
+	writeTestModule(t, root, "github.com/spf13/cobra v1.8.1")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\ntype Filter struct {\n\tName string `json:\"name\"`\n}\n\ntype Role string\n\ntype UserService interface {\n\tSearchUsers(limit int, filter Filter, role Role) ([]string, error)\n}\n")
+	out := filepath.Join(root, "out")
+
+	targets := []Target{{Name: "cli", Kind: "cli", Sources: []string{filepath.Join(root, "users")}, Output: out}}
+	if _, err := NewGenerator(WithTargets(targets...)).Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Without client_package there is no --url flag to store
+	if content, err := os.ReadFile(filepath.Join(out, "user_service_cli.go")); err != nil || strings.Contains(string(content), "url") {
+		t.Errorf("got %s, %v, want no url field", content, err)
+	}
+	writeTestFile(t, filepath.Join(out, "cli_test.go"), cliRuntimeTest)
+	goTest(t, root)
+}
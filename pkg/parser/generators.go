@@ -0,0 +1,101 @@
+package parser
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+)
+
+//go:embed default_templates/clientTemplate.txt
+var clientTemplate string
+
+//go:embed default_templates/protoTemplate.txt
+var protoTemplate string
+
+//go:embed default_templates/grpcTemplate.txt
+var grpcTemplate string
+
+//go:embed default_templates/connectTemplate.txt
+var connectTemplate string
+
+//go:embed default_templates/connectClientTemplate.txt
+var connectClientTemplate string
+
+//go:embed default_templates/jsonrpcTemplate.txt
+var jsonrpcTemplate string
+
+//go:embed default_templates/jsonrpcClientTemplate.txt
+var jsonrpcClientTemplate string
+
+//go:embed default_templates/mcpTemplate.txt
+var mcpTemplate string
+
+//go:embed default_templates/cliTemplate.txt
+var cliTemplate string
+
+//go:embed default_templates/pythonClientTemplate.txt
+var pythonClientTemplate string
+
+//go:embed default_templates/graphqlTemplate.txt
+var graphqlTemplate string
+
+//go:embed default_templates/graphqlResolverTemplate.txt
+var graphqlResolverTemplate string
+
+//go:embed default_templates/fakeTemplate.txt
+var fakeTemplate string
+
+//go:embed default_templates/recorderTemplate.txt
+var recorderTemplate string
+
+//go:embed default_templates/resilienceTemplate.txt
+var resilienceTemplate string
+
+// generatorKind describes the file a generator renders for every interface.
+type generatorKind struct {
+	Template     string // Built-in template
+	TemplateFile string // Name of the built-in template in default_templates
+	FileName     string // Output file name, %s is replaced with the snake case interface name
+	Plugin       string // Executable of an external generator, which renders all interfaces of a target at once
+}
+
+var generatorKinds = map[string]generatorKind{
+	"handler":           {Template: handlerTemplate, TemplateFile: "handlerTemplate.txt", FileName: "%s_handlers.go"},
+	"client":            {Template: clientTemplate, TemplateFile: "clientTemplate.txt", FileName: "%s_client.go"},
+	"proto":             {Template: protoTemplate, TemplateFile: "protoTemplate.txt", FileName: "%s.proto"},
+	"grpc":              {Template: grpcTemplate, TemplateFile: "grpcTemplate.txt", FileName: "%s_grpc.go"},
+	"connect":           {Template: connectTemplate, TemplateFile: "connectTemplate.txt", FileName: "%s_connect.go"},
+	"connect-client":    {Template: connectClientTemplate, TemplateFile: "connectClientTemplate.txt", FileName: "%s_connect_client.go"},
+	"jsonrpc":           {Template: jsonrpcTemplate, TemplateFile: "jsonrpcTemplate.txt", FileName: "%s_jsonrpc.go"},
+	"jsonrpc-client":    {Template: jsonrpcClientTemplate, TemplateFile: "jsonrpcClientTemplate.txt", FileName: "%s_jsonrpc_client.go"},
+	"mcp":               {Template: mcpTemplate, TemplateFile: "mcpTemplate.txt", FileName: "%s_mcp.go"},
+	"cli":               {Template: cliTemplate, TemplateFile: "cliTemplate.txt", FileName: "%s_cli.go"},
+	"python":            {Template: pythonClientTemplate, TemplateFile: "pythonClientTemplate.txt", FileName: "%s_client.py"},
+	"graphql":           {Template: graphqlTemplate, TemplateFile: "graphqlTemplate.txt", FileName: "%s.graphql"},
+	"graphql-resolvers": {Template: graphqlResolverTemplate, TemplateFile: "graphqlResolverTemplate.txt", FileName: "%s_graphql.go"},
+	"fake":              {Template: fakeTemplate, TemplateFile: "fakeTemplate.txt", FileName: "%s_fake.go"},
+	"recorder":          {Template: recorderTemplate, TemplateFile: "recorderTemplate.txt", FileName: "%s_recorder.go"},
+	"resilience":        {Template: resilienceTemplate, TemplateFile: "resilienceTemplate.txt", FileName: "%s_resilience.go"},
+}
+
+// GeneratorKinds lists the names of the available generators.
+func GeneratorKinds() []string {
+	var kinds []string
+	for kind := range generatorKinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// getGeneratorKind returns the built-in generator kind, or the plugin on the PATH implementing it.
+func getGeneratorKind(kind string) (generatorKind, error) {
+	g, ok := generatorKinds[kind]
+	if ok {
+		return g, nil
+	}
+	if plugin, err := lookupPlugin(kind); err == nil {
+		return plugin, nil
+	}
+	return generatorKind{}, fmt.Errorf("unknown generator kind %q, expected one of %v or a %s%s executable on the PATH", kind, GeneratorKinds(), PluginPrefix, kind)
+}
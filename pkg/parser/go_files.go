@@ -1,87 +1,127 @@
 package parser
 
 import (
+	"errors"
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/token"
 	"io/fs"
-	"os"
-	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
 // FileInterface represents a Go file, its package name, and the interfaces it contains.
 type FileInterface struct {
-	FilePath    string   // Relative file path
-	PackageName string   // Package name
-	Interfaces  []string // List of interface names
-	ImportName  string
-}
-
-func getRelativePath(fullPath string) string {
-	const prefix = "go/src/"
-	if idx := strings.Index(fullPath, prefix); idx != -1 {
-		return fullPath[idx+len(prefix):]
-	}
-	return fullPath // Return the original path if "go/src/" is not found
+	FilePath    string   `json:"filePath" yaml:"filePath"`                       // Relative file path
+	PackageName string   `json:"packageName" yaml:"packageName"`                 // Package name
+	Interfaces  []string `json:"interfaces" yaml:"interfaces"`                   // List of interface names
+	ImportName  string   `json:"importName" yaml:"importName"`                   // Import path of the package
+	Dir         string   `json:"dir" yaml:"dir"`                                 // Absolute directory of the package
+	ModuleDir   string   `json:"moduleDir,omitempty" yaml:"moduleDir,omitempty"` // Directory of the go.mod the package belongs to, empty in GOPATH mode
+	GoWork      string   `json:"goWork,omitempty" yaml:"goWork,omitempty"`       // go.work file the package is loaded with, see Module.GoWork
+	BuildTags   []string `json:"buildTags,omitempty" yaml:"buildTags,omitempty"` // Build tags the file was discovered with
 }
 
 // FindGoFilesWithInterfaces recursively searches for .go files containing interfaces.
 // If interfaceName is non-empty, it filters only files containing the specified interface.
 func FindGoFilesWithInterfaces(rootDir, interfaceName string, ignoreDirs ...string) ([]FileInterface, error) {
-	var result []FileInterface
-	currentDir, err := os.Getwd()
+	var sel Selection
+	if interfaceName != "" {
+		sel.Include = []string{interfaceName}
+	}
+	return FindInterfaces(rootDir, sel, ignoreDirs...)
+}
+
+// FindInterfaces recursively searches the packages below rootDir for the interfaces picked by sel.
+// Only the files matching the build context and sel.BuildTags are read, and the directories
+// the go tool ignores (vendor, testdata, names starting with . or _) are skipped along with ignoreDirs.
+func FindInterfaces(rootDir string, sel Selection, ignoreDirs ...string) ([]FileInterface, error) {
+	selected, err := sel.compile()
 	if err != nil {
 		return nil, err
 	}
-	currentDir = getRelativePath(currentDir)
-	err = filepath.WalkDir(rootDir, func(filePath string, d fs.DirEntry, err error) error {
-		if strings.Contains(filePath, "vendor") {
-			return filepath.SkipDir
+	ignored, err := absDirs(ignoreDirs)
+	if err != nil {
+		return nil, err
+	}
+	buildContext := build.Default
+	buildContext.BuildTags = append(slices.Clone(buildContext.BuildTags), sel.BuildTags...)
+
+	var result []FileInterface
+	modules := newModuleResolver()
+	err = filepath.WalkDir(rootDir, func(dirPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
 		}
-		for _, ignoreDir := range ignoreDirs {
-			if strings.Contains(filePath, ignoreDir) {
-				return filepath.SkipDir
-			}
+		if !d.IsDir() {
+			return nil
 		}
+		dir, err := filepath.Abs(dirPath)
 		if err != nil {
 			return err
 		}
-
-		// Skip directories
-		if d.IsDir() {
-			return nil
+		if dirPath != rootDir && skipDir(dir, ignored) {
+			return filepath.SkipDir
 		}
 
-		// Only process .go files
-		if !strings.HasSuffix(d.Name(), ".go") {
+		pkg, err := buildContext.ImportDir(dir, 0)
+		var noGo *build.NoGoError
+		if errors.As(err, &noGo) {
 			return nil
-		}
-
-		// Get interfaces and package name
-		interfaces, pkgName, err := getInterfacesAndPackage(filePath, interfaceName)
-		if err != nil {
+		} else if err != nil {
 			return err
 		}
+		fileNames := slices.Concat(pkg.GoFiles, pkg.CgoFiles)
+		if sel.Tests {
+			fileNames = slices.Concat(fileNames, pkg.TestGoFiles, pkg.XTestGoFiles)
+		}
+		slices.Sort(fileNames)
 
-		// Add to result if interfaces are found
-		if len(interfaces) > 0 {
-			relativePath, err := filepath.Rel(rootDir, filePath)
+		for _, fileName := range fileNames {
+			filePath := filepath.Join(dirPath, fileName)
+
+			// Get interfaces and package name
+			interfaces, pkgName, err := getInterfacesAndPackage(filePath)
 			if err != nil {
 				return err
 			}
-			f := strings.TrimSuffix(relativePath, "/"+pkgName+".go")
-			if strings.HasSuffix(f, ".go") {
-				s := strings.Split(f, "/")
-				f = strings.Join(s[:len(s)-1], "/")
+			if len(interfaces) == 0 {
+				continue
 			}
-			result = append(result, FileInterface{
+
+			importName, module, err := modules.importPath(dir)
+			if err != nil {
+				return err
+			}
+			var names []string
+			for _, i := range interfaces {
+				i.Package, i.ImportPath, i.Dir = pkgName, importName, dir
+				if selected.matches(i) {
+					names = append(names, i.Name)
+				}
+			}
+			if len(names) == 0 {
+				continue
+			}
+
+			relativePath, err := filepath.Rel(rootDir, filePath)
+			if err != nil {
+				return err
+			}
+			fileInterface := FileInterface{
 				FilePath:    relativePath,
 				PackageName: pkgName,
-				Interfaces:  interfaces,
-				ImportName:  path.Join(currentDir, f),
-			})
+				Interfaces:  names,
+				ImportName:  importName,
+				Dir:         dir,
+				BuildTags:   sel.BuildTags,
+			}
+			if module != nil {
+				fileInterface.ModuleDir, fileInterface.GoWork = module.Dir, module.GoWork
+			}
+			result = append(result, fileInterface)
 		}
 		return nil
 	})
@@ -93,17 +133,41 @@ func FindGoFilesWithInterfaces(rootDir, interfaceName string, ignoreDirs ...stri
 	return result, nil
 }
 
+// skipDir reports whether discovery skips dir, ignored holds absolute directories.
+func skipDir(dir string, ignored []string) bool {
+	name := filepath.Base(dir)
+	if name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return true
+	}
+	return slices.Contains(ignored, dir)
+}
+
+func absDirs(dirs []string) ([]string, error) {
+	result := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, abs)
+	}
+	return result, nil
+}
+
 // getInterfacesAndPackage retrieves all interfaces and the package name in a Go file.
-func getInterfacesAndPackage(filePath, interfaceName string) ([]string, string, error) {
+func getInterfacesAndPackage(filePath string) ([]interfaceInfo, string, error) {
 	fset := token.NewFileSet()
 
 	// Parse the file
-	node, err := parser.ParseFile(fset, filePath, nil, parser.AllErrors)
+	node, err := parser.ParseFile(fset, filePath, nil, parser.AllErrors|parser.ParseComments)
 	if err != nil {
 		return nil, "", err
 	}
 
-	var interfaces []string
+	var interfaces []interfaceInfo
 	packageName := node.Name.Name // Extract package name
 
 	// Traverse the AST to find interfaces
@@ -112,6 +176,11 @@ func getInterfacesAndPackage(filePath, interfaceName string) ([]string, string,
 		if !ok {
 			continue
 		}
+		// The doc of a grouped declaration does not belong to the types inside it
+		declDoc := genDecl.Doc
+		if genDecl.Lparen.IsValid() {
+			declDoc = nil
+		}
 		for _, spec := range genDecl.Specs {
 			typeSpec, ok := spec.(*ast.TypeSpec)
 			if !ok {
@@ -119,9 +188,10 @@ func getInterfacesAndPackage(filePath, interfaceName string) ([]string, string,
 			}
 			// Check if it's an interface type
 			if _, ok := typeSpec.Type.(*ast.InterfaceType); ok {
-				if interfaceName == "" || typeSpec.Name.Name == interfaceName {
-					interfaces = append(interfaces, typeSpec.Name.Name)
-				}
+				interfaces = append(interfaces, interfaceInfo{
+					Name:   typeSpec.Name.Name,
+					Marked: hasMarker(declDoc, typeSpec.Doc),
+				})
 			}
 		}
 	}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindInterfacesBuildContext(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "api.go"), "package root\n\ntype API interface {\n\tGet(id string) (string, error)\n}\n")
+	writeTestFile(t, filepath.Join(root, "gen.go"), "//go:build ignore\n\npackage main\n\ntype Generator interface {\n\tRun() error\n}\n")
+	writeTestFile(t, filepath.Join(root, "enterprise.go"), "//go:build enterprise\n\npackage root\n\ntype Audit interface {\n\tRecord(event string) error\n}\n")
+	writeTestFile(t, filepath.Join(root, "other_plan9.go"), "package root\n\ntype Plan9 interface {\n\tRun() error\n}\n")
+	writeTestFile(t, filepath.Join(root, "vendorish", "v.go"), "package vendorish\n\ntype Kept interface {\n\tRun() error\n}\n")
+	writeTestFile(t, filepath.Join(root, "vendor", "dep", "dep.go"), "package dep\n\ntype Vendored interface {\n\tRun() error\n}\n")
+	writeTestFile(t, filepath.Join(root, "testdata", "data.go"), "package data\n\ntype Data interface {\n\tRun() error\n}\n")
+	writeTestFile(t, filepath.Join(root, "out", "out.go"), "package out\n\ntype Output interface {\n\tRun() error\n}\n")
+
+	tests := []struct {
+		name       string
+		selection  Selection
+		ignoreDirs []string
+		expected   []string
+	}{
+		{"Defaults", Selection{}, nil, []string{"API", "Output", "Kept"}},
+		{"Tags", Selection{BuildTags: []string{"enterprise"}}, nil, []string{"API", "Audit", "Output", "Kept"}},
+		{"IgnoreDirs", Selection{}, []string{filepath.Join(root, "out")}, []string{"API", "Kept"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := FindInterfaces(root, tt.selection, tt.ignoreDirs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, file := range result {
+				names = append(names, file.Interfaces...)
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("got %v, want %v", names, tt.expected)
+			}
+		})
+	}
+
+	// The interface only exists with the tag, so loading has to use it as well
+	gofiles, err := FindInterfaces(root, Selection{Include: []string{"Audit"}, BuildTags: []string{"enterprise"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := RenderFiles(context.Background(), gofiles, RenderOptions{OutputDir: filepath.Join(root, "out")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || filepath.Base(files[0].Path) != "audit_handlers.go" {
+		t.Errorf("got %v, want audit_handlers.go", files)
+	}
+}
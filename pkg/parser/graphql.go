@@ -0,0 +1,222 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// GraphQLSchema is the GraphQL model of an interface, as returned by the graphqlSchema template function.
+// Methods inferred as GET requests are query fields, the others mutations.
+type GraphQLSchema struct {
+	Queries   []GraphQLField
+	Mutations []GraphQLField
+	Types     []GraphQLType   // Object and input types of the structs, in order of first use
+	Scalars   []GraphQLScalar // Custom scalars the schema uses
+}
+
+// Fields returns the query fields followed by the mutations.
+func (s *GraphQLSchema) Fields() []GraphQLField {
+	return append(slices.Clip(s.Queries), s.Mutations...)
+}
+
+type GraphQLField struct {
+	Name    string
+	Doc     string
+	Args    []GraphQLArg
+	Type    string
+	Returns []string // Names of the Go returns in the result, a Result type holds them when there are several
+	Method  Method
+}
+
+type GraphQLArg struct {
+	Name string
+	Type string
+}
+
+type GraphQLScalar struct {
+	Name string
+	Doc  string
+}
+
+type GraphQLType struct {
+	Name   string
+	Input  bool
+	Fields []GraphQLArg
+}
+
+// graphqlScalars describe the custom scalars standing in for Go types without a GraphQL equivalent.
+var graphqlScalars = map[string]string{
+	"Time":  "RFC 3339 timestamp",
+	"Bytes": "base64 encoded bytes",
+	"Map":   "JSON object",
+	"JSON":  "any JSON value",
+}
+
+// graphqlBuilder builds the GraphQLSchema of an interface.
+type graphqlBuilder struct {
+	im      *importer
+	schema  *GraphQLSchema
+	defs    map[string]TypeDef // Named types by their qualified Go type
+	names   map[string]string  // Type name by qualified Go type, with an "input " prefix for inputs
+	taken   map[string]bool
+	scalars map[string]bool
+}
+
+// buildGraphQLSchema returns the GraphQL model of the interface rendered with r.
+func buildGraphQLSchema(im *importer, r TemplateReplace) *GraphQLSchema {
+	b := &graphqlBuilder{
+		im:      im,
+		schema:  &GraphQLSchema{},
+		defs:    map[string]TypeDef{},
+		names:   map[string]string{},
+		taken:   map[string]bool{"Query": true, "Mutation": true},
+		scalars: map[string]bool{},
+	}
+	for name := range graphqlScalars {
+		b.taken[name] = true
+	}
+	for _, def := range r.Types {
+		b.defs[im.qualify(def.Name)] = def
+	}
+	for _, m := range r.Methods {
+		b.taken[m.Name+"Result"] = true
+	}
+
+	for _, m := range r.Methods {
+		field := GraphQLField{Name: lowerFirst(m.Name), Doc: m.Doc, Method: m}
+		for _, p := range m.Params {
+			field.Args = append(field.Args, GraphQLArg{Name: p.Name, Type: b.resolve(p.Type, true, true)})
+		}
+		names := returnNames(m.Returns)
+		var results []GraphQLArg
+		for i, ret := range m.Returns {
+			if ret.Type != "error" {
+				field.Returns = append(field.Returns, names[i])
+				results = append(results, GraphQLArg{Name: names[i], Type: b.resolve(ret.Type, false, true)})
+			}
+		}
+		switch len(results) {
+		case 0:
+			// Fields must have a type, methods without results return true
+			field.Type = "Boolean!"
+		case 1:
+			field.Type = results[0].Type
+		default:
+			field.Type = m.Name + "Result!"
+			b.schema.Types = append(b.schema.Types, GraphQLType{Name: m.Name + "Result", Fields: results})
+		}
+		if m.HTTPMethod == "GET" {
+			b.schema.Queries = append(b.schema.Queries, field)
+		} else {
+			b.schema.Mutations = append(b.schema.Mutations, field)
+		}
+	}
+
+	for scalar := range b.scalars {
+		b.schema.Scalars = append(b.schema.Scalars, GraphQLScalar{Name: scalar, Doc: graphqlScalars[scalar]})
+	}
+	sort.Slice(b.schema.Scalars, func(i, j int) bool { return b.schema.Scalars[i].Name < b.schema.Scalars[j].Name })
+	return b.schema
+}
+
+// resolve returns the GraphQL type of the Go type t, an input type when input is set.
+// Values are non null unless they are pointers, slices or maps, which encode nil as null.
+func (b *graphqlBuilder) resolve(t string, input, nonNull bool) string {
+	nullable := func(name string) string {
+		if nonNull {
+			return name + "!"
+		}
+		return name
+	}
+	switch t {
+	case "bool":
+		return nullable("Boolean")
+	case "string":
+		return nullable("String")
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte", "rune", "time.Duration":
+		return nullable("Int")
+	case "float32", "float64":
+		return nullable("Float")
+	case "[]byte", "[]uint8":
+		return b.scalar("Bytes")
+	case "time.Time":
+		return nullable(b.scalar("Time"))
+	}
+
+	switch {
+	case isVariadicType(t), isSliceType(t):
+		return "[" + b.resolve(elemType(t), input, true) + "]"
+	case isPointerType(t):
+		return b.resolve(elemType(t), input, false)
+	case isMapType(t):
+		return b.scalar("Map")
+	}
+
+	def, ok := b.defs[t]
+	if !ok || def.Kind == "interface" {
+		return b.scalar("JSON")
+	}
+	if def.Kind != "struct" {
+		return b.resolve(b.im.qualify(def.Underlying), input, nonNull)
+	}
+	return nullable(b.object(t, def, input))
+}
+
+// object returns the name of the object or input type of the struct t, adding it on first use.
+func (b *graphqlBuilder) object(t string, def TypeDef, input bool) string {
+	key := t
+	if input {
+		key = "input " + t
+	}
+	if name, ok := b.names[key]; ok {
+		return name
+	}
+	name := typeName(t)
+	if strings.ContainsAny(name, "[]") {
+		name = toPascalCase(strings.NewReplacer("[", " ", "]", " ", ",", " ", ".", " ", "*", " ").Replace(name))
+	}
+	if input {
+		name += "Input"
+	}
+	if b.taken[name] {
+		prefix := toPascalCase(typePackage(t)) + name
+		name = prefix
+		for i := 2; b.taken[name]; i++ {
+			name = fmt.Sprintf("%s%d", prefix, i)
+		}
+	}
+	b.taken[name] = true
+	b.names[key] = name
+
+	// Register the type before its fields, so recursive types terminate
+	index := len(b.schema.Types)
+	b.schema.Types = append(b.schema.Types, GraphQLType{Name: name, Input: input})
+	// Input fields are optional, as decoding leaves missing fields at their zero value
+	var fields []GraphQLArg
+	for _, f := range def.Fields {
+		fields = append(fields, GraphQLArg{Name: graphqlName(f.JSONName), Type: b.resolve(b.im.qualify(f.Type), input, !input && !f.OmitEmpty)})
+	}
+	b.schema.Types[index].Fields = fields
+	return name
+}
+
+func (b *graphqlBuilder) scalar(name string) string {
+	b.scalars[name] = true
+	return name
+}
+
+// graphqlName replaces the characters GraphQL names can not hold with underscores.
+func graphqlName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+	if name == "" || '0' <= name[0] && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
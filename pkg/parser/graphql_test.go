@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGraphQLSchema(t *testing.T) {
+	im := newImporter(nil)
+	im.reserve("example.com/app/users", "users")
+	schema := buildGraphQLSchema(im, TemplateReplace{
+		PackageName:   "users",
+		InterfaceName: "UserService",
+		Methods: []Method{{
+			Name:       "ListUsers",
+			HTTPMethod: "GET",
+			Params:     []Param{{Name: "since", Type: "*time.Time"}, {Name: "roles", Type: "...users.Role"}},
+			Returns:    []Return{{Type: "[]users.User"}, {Type: "int"}, {Type: "error"}},
+		}, {
+			Name:       "CreateUser",
+			HTTPMethod: "POST",
+			Params:     []Param{{Name: "user", Type: "users.User"}},
+			Returns:    []Return{{Type: "error"}},
+		}},
+		Types: []TypeDef{
+			{Name: "example.com/app/users.Role", Kind: "basic", Underlying: "string"},
+			{Name: "example.com/app/users.User", Kind: "struct", Fields: []Field{
+				{Name: "ID", Type: "string", JSONName: "id"},
+				{Name: "Nick", Type: "string", JSONName: "nick", OmitEmpty: true},
+				{Name: "Friends", Type: "[]*example.com/app/users.User", JSONName: "friends"},
+				{Name: "Labels", Type: "map[string]string", JSONName: "labels"},
+			}},
+		},
+	})
+
+	expectedQueries := []GraphQLField{{
+		Name:    "listUsers",
+		Args:    []GraphQLArg{{Name: "since", Type: "Time"}, {Name: "roles", Type: "[String!]"}},
+		Type:    "ListUsersResult!",
+		Returns: []string{"result0", "result1"},
+	}}
+	expectedMutations := []GraphQLField{{
+		Name: "createUser",
+		Args: []GraphQLArg{{Name: "user", Type: "UserInput!"}},
+		Type: "Boolean!",
+	}}
+	for i := range schema.Queries {
+		schema.Queries[i].Method = Method{}
+	}
+	for i := range schema.Mutations {
+		schema.Mutations[i].Method = Method{}
+	}
+	if !reflect.DeepEqual(schema.Queries, expectedQueries) || !reflect.DeepEqual(schema.Mutations, expectedMutations) {
+		t.Errorf("got queries %+v and mutations %+v", schema.Queries, schema.Mutations)
+	}
+
+	expectedTypes := []GraphQLType{
+		{Name: "User", Fields: []GraphQLArg{{Name: "id", Type: "String!"}, {Name: "nick", Type: "String"}, {Name: "friends", Type: "[User]"}, {Name: "labels", Type: "Map"}}},
+		{Name: "ListUsersResult", Fields: []GraphQLArg{{Name: "result0", Type: "[User!]"}, {Name: "result1", Type: "Int!"}}},
+		{Name: "UserInput", Input: true, Fields: []GraphQLArg{{Name: "id", Type: "String"}, {Name: "nick", Type: "String"}, {Name: "friends", Type: "[UserInput]"}, {Name: "labels", Type: "Map"}}},
+	}
+	if !reflect.DeepEqual(schema.Types, expectedTypes) {
+		t.Errorf("got types %+v, want %+v", schema.Types, expectedTypes)
+	}
+	if !reflect.DeepEqual(schema.Scalars, []GraphQLScalar{{Name: "Map", Doc: "JSON object"}, {Name: "Time", Doc: "RFC 3339 timestamp"}}) {
+		t.Errorf("got scalars %+v", schema.Scalars)
+	}
+}
@@ -0,0 +1,341 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// ImplOptions configures ImplementInterfaces.
+type ImplOptions struct {
+	OutputDir string // Package directory of the implementations
+	Type      string // Name of the implementing type, <Interface>Impl when empty. Only valid for a single interface
+}
+
+// implTarget is what the output package already declares for an implementing type.
+type implTarget struct {
+	file     string          // File declaring the type, empty when it is not declared yet
+	receiver string          // Receiver name of the existing methods
+	methods  map[string]bool // Methods that are already written
+}
+
+// ImplementInterfaces renders a stub type for every interface of gofiles. Every method
+// returns zero values and a not implemented error, or panics when it can not return an
+// error. When the output package already declares the type, only its missing methods
+// are appended to the file declaring it and the methods that exist are left untouched.
+func ImplementInterfaces(ctx context.Context, gofiles []FileInterface, opts ImplOptions) ([]GeneratedFile, error) {
+	var count int
+	for _, file := range gofiles {
+		count += len(file.Interfaces)
+	}
+	if opts.Type != "" && count > 1 {
+		return nil, fmt.Errorf("a type name can only be set for a single interface, %d are selected", count)
+	}
+	outputDir, err := filepath.Abs(opts.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+	outPath, _, err := newModuleResolver().importPath(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, err := loadPackages(ctx, gofiles, nil, loadOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var files []GeneratedFile
+	for _, file := range gofiles {
+		pkg, err := loaded.Lookup(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range file.Interfaces {
+			obj := pkg.Types.Scope().Lookup(name)
+			if obj == nil || !types.IsInterface(obj.Type()) {
+				return nil, fmt.Errorf("interface %s not found in %s", name, pkg.PkgPath)
+			}
+			if named, ok := obj.Type().(*types.Named); !ok || named.TypeParams().Len() > 0 {
+				return nil, fmt.Errorf("%s.%s is generic, generic interfaces are not supported", pkg.PkgPath, name)
+			}
+			typeName := orFunc(opts.Type, name+"Impl")
+			target, err := findImplTarget(outputDir, typeName)
+			if err != nil {
+				return nil, err
+			}
+			f, err := renderImpl(obj.Type().(*types.Named), typeName, target, outputDir, outPath, loaded.names)
+			if err != nil {
+				return nil, err
+			}
+			f.Interface = name
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// findImplTarget looks for the declaration and the methods of typeName in the Go files of dir.
+func findImplTarget(dir, typeName string) (implTarget, error) {
+	target := implTarget{methods: map[string]bool{}}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return target, err
+	}
+	sort.Strings(paths)
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return target, err
+		}
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if spec, ok := spec.(*ast.TypeSpec); ok && spec.Name.Name == typeName {
+						target.file = path
+					}
+				}
+			case *ast.FuncDecl:
+				if decl.Recv == nil || len(decl.Recv.List) == 0 || receiverTypeName(decl.Recv.List[0].Type) != typeName {
+					continue
+				}
+				target.methods[decl.Name.Name] = true
+				if names := decl.Recv.List[0].Names; len(names) > 0 && names[0].Name != "_" && target.receiver == "" {
+					target.receiver = names[0].Name
+				}
+			}
+		}
+	}
+	return target, nil
+}
+
+// receiverTypeName returns the name of the type of a method receiver.
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// renderImpl renders the stubs of the methods of iface that target does not have yet.
+func renderImpl(iface *types.Named, typeName string, target implTarget, outputDir, outPath string, names map[string]string) (GeneratedFile, error) {
+	path := target.file
+	if path == "" {
+		path = filepath.Join(outputDir, toSnakeCase(typeName)+".go")
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return GeneratedFile{}, err
+	}
+
+	// Packages the existing file imports keep their names
+	fset := token.NewFileSet()
+	var file *ast.File
+	im := newImporter(names)
+	if existing != nil {
+		if file, err = parser.ParseFile(fset, path, existing, parser.ParseComments); err != nil {
+			return GeneratedFile{}, err
+		}
+		for _, spec := range file.Imports {
+			importPath, _ := strconv.Unquote(spec.Path.Value)
+			name := im.packageName(importPath)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			im.reserve(importPath, name)
+		}
+	}
+	qualify := func(p *types.Package) string {
+		if p.Path() == outPath {
+			return ""
+		}
+		return im.alias(p.Path())
+	}
+
+	ifaceName := types.TypeString(iface, qualify)
+	var body bytes.Buffer
+	if target.file == "" {
+		fmt.Fprintf(&body, "\n// %s implements %s.\ntype %s struct{}\n", typeName, ifaceName, typeName)
+	}
+	methodSet := iface.Underlying().(*types.Interface)
+	var methods []*types.Func
+	for i := 0; i < methodSet.NumMethods(); i++ {
+		if m := methodSet.Method(i); !target.methods[m.Name()] {
+			methods = append(methods, m)
+		}
+	}
+	sortByDeclaration(methods, iface.Obj().Pkg())
+	receiver := target.receiver
+	if receiver == "" {
+		receiver = implReceiver(typeName, methods)
+	}
+	for _, m := range methods {
+		// The receiver of existing methods is kept, the params named like it or like a package
+		// the stub refers to are renamed
+		reserved := []string{receiver, "errors"}
+		results := m.Type().(*types.Signature).Results()
+		for j := 0; j < results.Len(); j++ {
+			types.TypeString(results.At(j).Type(), func(p *types.Package) string {
+				name := qualify(p)
+				reserved = append(reserved, name)
+				return name
+			})
+		}
+		sig := implSignature(m.Type().(*types.Signature), reserved...)
+		fmt.Fprintf(&body, "\n// %s implements %s.\nfunc (%s *%s) %s", m.Name(), ifaceName, receiver, typeName, m.Name())
+		types.WriteSignature(&body, sig, qualify)
+		body.WriteString(" {\n")
+		var values []string
+		var hasError bool
+		for j := 0; j < sig.Results().Len(); j++ {
+			t := sig.Results().At(j).Type()
+			if types.Identical(t, types.Universe.Lookup("error").Type()) {
+				hasError = true
+				values = append(values, `errors.New("not implemented")`)
+				continue
+			}
+			values = append(values, implZero(t, qualify))
+		}
+		if hasError {
+			fmt.Fprintf(&body, "\treturn %s\n", strings.Join(values, ", "))
+		} else {
+			body.WriteString("\tpanic(\"not implemented\")\n")
+		}
+		body.WriteString("}\n")
+	}
+	if body.Len() == 0 {
+		return GeneratedFile{Path: path, Content: existing}, nil
+	}
+
+	var src bytes.Buffer
+	if existing != nil {
+		src.Write(bytes.TrimRight(existing, "\n"))
+		src.WriteString("\n")
+	} else {
+		fmt.Fprintf(&src, "package %s\n", outputPackageName(outputDir, names, outPath))
+	}
+	src.Write(body.Bytes())
+
+	// Add the imports the stubs use to the ones the file already has
+	if file, err = parser.ParseFile(fset, path, src.Bytes(), parser.ParseComments); err != nil {
+		return GeneratedFile{}, fmt.Errorf("failed to parse the %s stubs: %w", typeName, err)
+	}
+	paths, aliases := im.imports()
+	paths["errors"] = true
+	for importPath := range paths {
+		astutil.AddNamedImport(fset, file, aliases[importPath], importPath)
+	}
+	src.Reset()
+	if err := printer.Fprint(&src, fset, file); err != nil {
+		return GeneratedFile{}, err
+	}
+	content, err := formatGenerated(path, src.Bytes())
+	if err != nil {
+		return GeneratedFile{}, fmt.Errorf("failed to format the %s stubs: %w", typeName, err)
+	}
+	return GeneratedFile{Path: path, Content: content}, nil
+}
+
+// implReceiver returns the receiver name of the stubs of methods, the first letter of typeName
+// unless a param or result uses it.
+func implReceiver(typeName string, methods []*types.Func) string {
+	used := map[string]bool{}
+	for _, m := range methods {
+		sig := m.Type().(*types.Signature)
+		for _, tuple := range []*types.Tuple{sig.Params(), sig.Results()} {
+			for i := 0; i < tuple.Len(); i++ {
+				used[tuple.At(i).Name()] = true
+			}
+		}
+	}
+	receiver := strings.ToLower(typeName[:1])
+	if !used[receiver] {
+		return receiver
+	}
+	if name := lowerFirst(typeName); !used[name] && !token.IsKeyword(name) {
+		return name
+	}
+	for i := 1; ; i++ {
+		if name := fmt.Sprintf("%s%d", receiver, i); !used[name] {
+			return name
+		}
+	}
+}
+
+// implSignature returns sig with the params and results named like one of reserved renamed.
+func implSignature(sig *types.Signature, reserved ...string) *types.Signature {
+	isReserved := map[string]bool{}
+	used := map[string]bool{}
+	for _, name := range reserved {
+		if name != "" {
+			isReserved[name] = true
+			used[name] = true
+		}
+	}
+	for _, tuple := range []*types.Tuple{sig.Params(), sig.Results()} {
+		for i := 0; i < tuple.Len(); i++ {
+			used[tuple.At(i).Name()] = true
+		}
+	}
+	rename := func(tuple *types.Tuple) *types.Tuple {
+		vars := make([]*types.Var, tuple.Len())
+		for i := range vars {
+			v := tuple.At(i)
+			name := v.Name()
+			for j := 1; isReserved[name] || name != v.Name() && used[name]; j++ {
+				name = fmt.Sprintf("%s%d", v.Name(), j)
+			}
+			used[name] = true
+			vars[i] = types.NewVar(v.Pos(), v.Pkg(), name, v.Type())
+		}
+		return types.NewTuple(vars...)
+	}
+	return types.NewSignatureType(nil, nil, nil, rename(sig.Params()), rename(sig.Results()), sig.Variadic())
+}
+
+// implZero returns an expression for the zero value of t.
+func implZero(t types.Type, qualify types.Qualifier) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			if _, ok := t.(*types.Basic); ok {
+				return `""`
+			}
+			return types.TypeString(t, qualify) + `("")`
+		case u.Info()&types.IsNumeric != 0:
+			if _, ok := t.(*types.Basic); ok {
+				return "0"
+			}
+			return types.TypeString(t, qualify) + "(0)"
+		}
+	case *types.Struct, *types.Array:
+		return types.TypeString(t, qualify) + "{}"
+	}
+	return "nil"
+}
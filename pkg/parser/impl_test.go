@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImplementInterfaces(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), `package users
+
+import "context"
+
+type User struct{ Name string }
+
+type UserService interface {
+	GetUser(ctx context.Context, id string) (*User, error)
+	CountUsers() (int, User, error)
+	Reset()
+}
+`)
+	gofiles, err := FindInterfaces(root, Selection{Include: []string{"UserService"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		existing string // Content of service.go, which declares the type when set
+		contains []string
+		missing  []string
+	}{
+		{
+			name: "new type",
+			contains: []string{
+				"package impl",
+				"type Service struct{}",
+				"func (s *Service) GetUser(ctx context.Context, id string) (*users.User, error) {\n\treturn nil, errors.New(\"not implemented\")\n}",
+				"func (s *Service) CountUsers() (int, users.User, error) {\n\treturn 0, users.User{}, errors.New(\"not implemented\")\n}",
+				"func (s *Service) Reset() {\n\tpanic(\"not implemented\")\n}",
+			},
+		},
+		{
+			name:     "missing methods",
+			existing: "package impl\n\nimport u \"example.com/root/users\"\n\ntype Service struct{}\n\nfunc (svc Service) CountUsers() (int, u.User, error) {\n\treturn 1, u.User{}, nil\n}\n",
+			contains: []string{
+				"return 1, u.User{}, nil",
+				"func (svc *Service) GetUser(ctx context.Context, id string) (*u.User, error) {",
+				"func (svc *Service) Reset() {",
+			},
+			missing: []string{"CountUsers implements", "\t\"example.com/root/users\""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(root, strings.ReplaceAll(tt.name, " ", "_"), "impl")
+			if err := os.MkdirAll(out, os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			if tt.existing != "" {
+				writeTestFile(t, filepath.Join(out, "service.go"), tt.existing)
+			}
+			files, err := ImplementInterfaces(context.Background(), gofiles, ImplOptions{OutputDir: out, Type: "Service"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 1 || files[0].Path != filepath.Join(out, "service.go") {
+				t.Fatalf("got %+v, want service.go", files)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(files[0].Content), want) {
+					t.Errorf("got %s, want it to contain %s", files[0].Content, want)
+				}
+			}
+			for _, unwanted := range tt.missing {
+				if strings.Contains(string(files[0].Content), unwanted) {
+					t.Errorf("got %s, want it not to contain %s", files[0].Content, unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestImplementInterfacesReceiverCollision(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), `package users
+
+type User struct{}
+
+type Searcher interface {
+	Search(s string, svc int) (n int, err error)
+	Validate(errors []string) error
+	Lookup(users string) (User, error)
+}
+`)
+	gofiles, err := FindInterfaces(root, Selection{Include: []string{"Searcher"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		existing string
+		expected []string
+	}{
+		// The receiver is renamed when a param uses it, the params named like the packages the stubs use are
+		{"new type", "", []string{
+			"func (service *Service) Search(s string, svc int) (n int, err error) {",
+			"func (service *Service) Validate(errors1 []string) error {\n\treturn errors.New(\"not implemented\")",
+			"func (service *Service) Lookup(users1 string) (users.User, error) {\n\treturn users.User{}, errors.New(\"not implemented\")",
+		}},
+		// The receiver of the existing methods is kept, the param is renamed
+		{"existing receiver", "package impl\n\ntype Service struct{}\n\nfunc (svc *Service) Close() {}\n", []string{
+			"func (svc *Service) Search(s string, svc1 int) (n int, err error) {",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(root, strings.ReplaceAll(tt.name, " ", "_"), "impl")
+			if err := os.MkdirAll(out, os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			if tt.existing != "" {
+				writeTestFile(t, filepath.Join(out, "service.go"), tt.existing)
+			}
+			files, err := ImplementInterfaces(context.Background(), gofiles, ImplOptions{OutputDir: out, Type: "Service"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 1 {
+				t.Fatalf("got %+v, want service.go", files)
+			}
+			for _, want := range tt.expected {
+				if !strings.Contains(string(files[0].Content), want) {
+					t.Errorf("got %s, want it to contain %s", files[0].Content, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultAssertionsFile is the file implementation assertions are written to in every package.
+const DefaultAssertionsFile = "interfacery_assertions.go"
+
+// Implementation is a type implementing a selected interface.
+type Implementation struct {
+	Interface string `json:"interface" yaml:"interface"` // Qualified interface, such as example.com/app/pkg/users.UserService
+	Type      string `json:"type" yaml:"type"`           // Qualified implementing type
+	Pointer   bool   `json:"pointer" yaml:"pointer"`     // Only the pointer to the type implements the interface
+	Pos       string `json:"pos" yaml:"pos"`             // Position of the type declaration
+	Dir       string `json:"-" yaml:"-"`
+}
+
+// ImplementationsOptions configures FindImplementations.
+type ImplementationsOptions struct {
+	SearchDir      string   // Packages below this directory are searched for implementations
+	AssertionsFile string   // Name of the assertions file of every package, DefaultAssertionsFile when empty
+	BuildTags      []string // Additional build tags satisfied when loading the packages
+}
+
+// Implementations are the implementations found by FindImplementations.
+type Implementations struct {
+	List []Implementation
+	// Assertions holds a file per package with implementations, asserting that its types
+	// keep implementing the interfaces, so that a method added to an interface fails to
+	// compile at the implementation. Existing assertion files of packages without
+	// implementations are emptied.
+	Assertions []GeneratedFile
+}
+
+// FindImplementations loads every package below opts.SearchDir and returns the defined
+// types that implement the interfaces of gofiles, either directly or through a pointer.
+// Previously written assertion files are ignored while loading, so stale assertions do
+// not keep the packages from loading.
+func FindImplementations(ctx context.Context, gofiles []FileInterface, opts ImplementationsOptions) (*Implementations, error) {
+	fileName := orFunc(opts.AssertionsFile, DefaultAssertionsFile)
+	searched, err := packageDirs(opts.SearchDir, opts.BuildTags)
+	if err != nil {
+		return nil, err
+	}
+	overlay := map[string][]byte{}
+	for _, pkg := range searched {
+		path := filepath.Join(pkg.Dir, fileName)
+		for name, content := range packageClauseOverlay(path) {
+			overlay[name] = content
+		}
+	}
+	loaded, err := loadPackages(ctx, slices.Concat(gofiles, searched), nil, loadOptions{overlay: overlay, typeErrors: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var interfaces []*types.Named
+	for _, file := range gofiles {
+		pkg, err := loaded.Lookup(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range file.Interfaces {
+			obj := pkg.Types.Scope().Lookup(name)
+			if obj == nil || !types.IsInterface(obj.Type()) {
+				return nil, fmt.Errorf("interface %s not found in %s", name, pkg.PkgPath)
+			}
+			named, ok := obj.Type().(*types.Named)
+			// Every type implements an empty interface
+			if !ok || named.TypeParams().Len() > 0 || named.Underlying().(*types.Interface).NumMethods() == 0 {
+				continue
+			}
+			interfaces = append(interfaces, named)
+		}
+	}
+
+	result := &Implementations{}
+	byDir := map[string][][2]*types.Named{} // Interface and type pairs by package directory
+	for _, file := range searched {
+		pkg, err := loaded.Lookup(file)
+		if err != nil {
+			return nil, err
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			named, ok := obj.Type().(*types.Named)
+			if !ok || types.IsInterface(named) || named.TypeParams().Len() > 0 {
+				continue
+			}
+			for _, iface := range interfaces {
+				pointer := !types.Implements(named, iface.Underlying().(*types.Interface))
+				if pointer && !types.Implements(types.NewPointer(named), iface.Underlying().(*types.Interface)) {
+					continue
+				}
+				result.List = append(result.List, Implementation{
+					Interface: types.TypeString(iface, qualifyByPath),
+					Type:      types.TypeString(named, qualifyByPath),
+					Pointer:   pointer,
+					Pos:       loaded.Fset.Position(obj.Pos()).String(),
+					Dir:       file.Dir,
+				})
+				// Types of test files can not be referred to by the assertions
+				if !strings.HasSuffix(loaded.Fset.Position(obj.Pos()).Filename, "_test.go") {
+					byDir[file.Dir] = append(byDir[file.Dir], [2]*types.Named{iface, named})
+				}
+			}
+		}
+	}
+	sort.SliceStable(result.List, func(i, j int) bool { return result.List[i].Interface < result.List[j].Interface })
+
+	for _, file := range searched {
+		path := filepath.Join(file.Dir, fileName)
+		if _, exists := overlay[path]; !exists && len(byDir[file.Dir]) == 0 {
+			continue
+		}
+		// Existing assertions are kept while their types exist, so that rerunning the
+		// command does not drop the assertion of an implementation that broke
+		var pairs [][2]*types.Named
+		seen := map[[2]*types.Named]bool{}
+		for _, pair := range slices.Concat(existingAssertions(path, file.ImportName, loaded), byDir[file.Dir]) {
+			if !seen[pair] {
+				seen[pair] = true
+				pairs = append(pairs, pair)
+			}
+		}
+		content, err := renderAssertions(path, file.PackageName, file.ImportName, pairs, loaded.names)
+		if err != nil {
+			return nil, err
+		}
+		result.Assertions = append(result.Assertions, GeneratedFile{Path: path, Content: content})
+	}
+	return result, nil
+}
+
+// existingAssertions returns the interface and type pairs asserted by the file at path,
+// skipping those whose interface or type no longer exists.
+func existingAssertions(path, importPath string, loaded *Packages) [][2]*types.Named {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	imports := map[string]string{} // Package name -> import path
+	for _, spec := range f.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		name := newImporter(loaded.names).packageName(p)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = p
+	}
+	lookup := func(expr ast.Expr) *types.Named {
+		p, name := importPath, ""
+		switch e := expr.(type) {
+		case *ast.Ident:
+			name = e.Name
+		case *ast.SelectorExpr:
+			pkg, ok := e.X.(*ast.Ident)
+			if !ok {
+				return nil
+			}
+			p, name = imports[pkg.Name], e.Sel.Name
+		}
+		pkg := loaded.byPath[p]
+		if pkg == nil || pkg.Types == nil {
+			return nil
+		}
+		obj, ok := pkg.Types.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return nil
+		}
+		named, _ := obj.Type().(*types.Named)
+		return named
+	}
+
+	var pairs [][2]*types.Named
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || len(spec.Values) != 1 || spec.Type == nil {
+			return true
+		}
+		// _ Interface = (*Type)(nil)
+		call, ok := spec.Values[0].(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		paren, ok := call.Fun.(*ast.ParenExpr)
+		if !ok {
+			return true
+		}
+		star, ok := paren.X.(*ast.StarExpr)
+		if !ok {
+			return true
+		}
+		iface, typ := lookup(spec.Type), lookup(star.X)
+		if iface != nil && typ != nil && types.IsInterface(iface) && !types.IsInterface(typ) {
+			pairs = append(pairs, [2]*types.Named{iface, typ})
+		}
+		return true
+	})
+	return pairs
+}
+
+// renderAssertions renders the assertions of a package that each type implements its interface.
+func renderAssertions(path, packageName, importPath string, pairs [][2]*types.Named, names map[string]string) ([]byte, error) {
+	im := newImporter(names)
+	qualify := func(p *types.Package) string {
+		if p.Path() == importPath {
+			return ""
+		}
+		return im.alias(p.Path())
+	}
+	var body bytes.Buffer
+	if len(pairs) > 0 {
+		body.WriteString("// The types of this package implement these interfaces, a method added to\n")
+		body.WriteString("// an interface fails to compile here until the type implements it too.\n")
+		body.WriteString("var (\n")
+		for _, pair := range pairs {
+			fmt.Fprintf(&body, "\t_ %s = (*%s)(nil)\n", types.TypeString(pair[0], qualify), types.TypeString(pair[1], qualify))
+		}
+		body.WriteString(")\n")
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "package %s\n\n", packageName)
+	writeImports(&src, im)
+	src.Write(body.Bytes())
+	return formatGenerated(path, src.Bytes())
+}
+
+// packageDirs returns a FileInterface without interfaces for every package below
+// rootDir, skipping the directories the go tool ignores.
+func packageDirs(rootDir string, tags []string) ([]FileInterface, error) {
+	buildContext := build.Default
+	buildContext.BuildTags = append(slices.Clone(buildContext.BuildTags), tags...)
+	modules := newModuleResolver()
+	var result []FileInterface
+	err := filepath.WalkDir(rootDir, func(dirPath string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		dir, err := filepath.Abs(dirPath)
+		if err != nil {
+			return err
+		}
+		if dirPath != rootDir && skipDir(dir, nil) {
+			return filepath.SkipDir
+		}
+		pkg, err := buildContext.ImportDir(dir, 0)
+		var noGo *build.NoGoError
+		if errors.As(err, &noGo) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		fileNames := slices.Concat(pkg.GoFiles, pkg.CgoFiles)
+		if len(fileNames) == 0 {
+			return nil
+		}
+		importName, module, err := modules.importPath(dir)
+		if err != nil {
+			return err
+		}
+		file := FileInterface{
+			FilePath:    filepath.Join(dirPath, fileNames[0]),
+			PackageName: pkg.Name,
+			ImportName:  importName,
+			Dir:         dir,
+			BuildTags:   tags,
+		}
+		if module != nil {
+			file.ModuleDir, file.GoWork = module.Dir, module.GoWork
+		}
+		result = append(result, file)
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("search directory %s does not exist", rootDir)
+	}
+	return result, err
+}
@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindImplementations(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\ntype UserService interface {\n\tGetUser(id string) (string, error)\n}\n\ntype Local struct{}\n\nfunc (Local) GetUser(id string) (string, error) { return id, nil }\n")
+	writeTestFile(t, filepath.Join(root, "impl", "impl.go"), "package impl\n\ntype Service struct{}\n\nfunc (s *Service) GetUser(id string) (string, error) { return id, nil }\n\ntype Other struct{}\n")
+	gofiles, err := FindInterfaces(filepath.Join(root, "users"), Selection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := ImplementationsOptions{SearchDir: root}
+
+	found, err := FindImplementations(context.Background(), gofiles, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, i := range found.List {
+		got = append(got, i.Type+" "+map[bool]string{true: "pointer", false: "value"}[i.Pointer])
+	}
+	if strings.Join(got, ", ") != "example.com/root/impl.Service pointer, example.com/root/users.Local value" {
+		t.Errorf("got %v, want Service through a pointer and Local", got)
+	}
+	if len(found.Assertions) != 2 {
+		t.Fatalf("got %d assertion files, want 2", len(found.Assertions))
+	}
+	assertions := found.Assertions[0]
+	if assertions.Path != filepath.Join(root, "impl", DefaultAssertionsFile) || !strings.Contains(string(assertions.Content), "_ users.UserService = (*Service)(nil)") {
+		t.Errorf("got %s at %s, want the Service assertion", assertions.Content, assertions.Path)
+	}
+	for _, f := range found.Assertions {
+		writeTestFile(t, f.Path, string(f.Content))
+	}
+
+	// Adding a method breaks the written assertion, which is kept when rerunning
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\ntype UserService interface {\n\tGetUser(id string) (string, error)\n\tDeleteUser(id string) error\n}\n")
+	found, err = FindImplementations(context.Background(), gofiles, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found.List) != 0 {
+		t.Errorf("got %+v, want no implementations", found.List)
+	}
+	if len(found.Assertions) != 2 || found.Assertions[0].Path != assertions.Path || string(found.Assertions[0].Content) != string(assertions.Content) {
+		t.Errorf("got %+v, want the Service assertion to be kept", found.Assertions)
+	}
+	if strings.Contains(string(found.Assertions[1].Content), "Local") {
+		t.Errorf("got %s, want the assertion of the removed Local type to be dropped", found.Assertions[1].Content)
+	}
+}
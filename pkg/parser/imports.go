@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// templateImports are the packages the built-in templates import themselves.
+// Their names are reserved so that user packages with the same name get an alias.
+var templateImports = map[string]string{
+	"bytes":         "bytes",
+	"context":       "context",
+	"encoding/json": "json",
+	"errors":        "errors",
+	"fmt":           "fmt",
+	"io":            "io",
+	"mime":          "mime",
+	"net/http":      "http",
+	"net/url":       "url",
+	"strconv":       "strconv",
+	"strings":       "strings",
+	"time":          "time",
+	"github.com/Seann-Moser/go-serve/server/endpoints": "endpoints",
+	"google.golang.org/grpc":                           "grpc",
+}
+
+var (
+	majorVersion   = regexp.MustCompile(`^v[0-9]+$`)
+	gopkgInVersion = regexp.MustCompile(`\.v[0-9]+$`)
+)
+
+// importer assigns aliases to the packages referenced by generated code, so
+// that fully qualified types like "example.com/pkg/users.User" can be written
+// as "users.User".
+type importer struct {
+	names   map[string]string // import path -> package name, as declared by the package
+	aliases map[string]string // import path -> identifier used in generated code
+	taken   map[string]string // identifier -> import path
+	used    map[string]bool   // import paths referenced by generated code
+	self    map[string]bool   // import paths the templates import on their own
+}
+
+func newImporter(names map[string]string) *importer {
+	im := &importer{
+		names:   map[string]string{},
+		aliases: map[string]string{},
+		taken:   map[string]string{},
+		used:    map[string]bool{},
+		self:    map[string]bool{},
+	}
+	for importPath, name := range names {
+		im.names[importPath] = name
+	}
+	for importPath, name := range templateImports {
+		im.names[importPath] = name
+		im.aliases[importPath] = name
+		im.taken[name] = importPath
+	}
+	return im
+}
+
+// reserve registers importPath under name without marking it as used, for
+// packages the templates import on their own such as the source package.
+func (im *importer) reserve(importPath, name string) {
+	im.self[importPath] = true
+	im.names[importPath] = name
+	im.aliases[importPath] = name
+	im.taken[name] = importPath
+}
+
+// alias returns the identifier for importPath and marks the package as used.
+func (im *importer) alias(importPath string) string {
+	im.used[importPath] = true
+	if a, ok := im.aliases[importPath]; ok {
+		return a
+	}
+	name := im.packageName(importPath)
+	a := name
+	for i := 1; im.taken[a] != ""; i++ {
+		a = name + strconv.Itoa(i)
+	}
+	im.aliases[importPath] = a
+	im.taken[a] = importPath
+	return a
+}
+
+// packageName returns the declared package name, or guesses it from the import path.
+func (im *importer) packageName(importPath string) string {
+	if name, ok := im.names[importPath]; ok {
+		return name
+	}
+	name := path.Base(importPath)
+	if majorVersion.MatchString(name) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+	name = gopkgInVersion.ReplaceAllString(name, "")
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "pkg" + name
+	}
+	return name
+}
+
+// qualify rewrites every package path in a type string produced by exprToString
+// to the alias of that package, e.g. "[]*example.com/pkg/users.User" becomes "[]*users.User".
+func (im *importer) qualify(typeString string) string {
+	var b strings.Builder
+	for i := 0; i < len(typeString); {
+		if !isTypePathChar(rune(typeString[i])) {
+			b.WriteByte(typeString[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(typeString) && isTypePathChar(rune(typeString[j])) {
+			j++
+		}
+		token := typeString[i:j]
+		// Variadic parameters are written as "...T"
+		trimmed := strings.TrimLeft(token, ".")
+		b.WriteString(token[:len(token)-len(trimmed)])
+		if dot := strings.LastIndex(trimmed, "."); dot > 0 {
+			pkg := trimmed[:dot]
+			// Already qualified with an alias, e.g. when qualify is called on a Param.Type
+			if a, ok := im.taken[pkg]; ok && !strings.Contains(pkg, "/") && im.aliases[a] == pkg {
+				pkg = a
+			}
+			b.WriteString(im.alias(pkg))
+			b.WriteString(trimmed[dot:])
+		} else {
+			b.WriteString(trimmed)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// imports returns the used import paths and the aliases of those whose alias differs from the package name.
+func (im *importer) imports() (map[string]bool, map[string]string) {
+	paths := map[string]bool{}
+	aliases := map[string]string{}
+	for importPath := range im.used {
+		if im.self[importPath] {
+			continue
+		}
+		paths[importPath] = true
+		if a := im.aliases[importPath]; a != im.packageName(importPath) || a != path.Base(importPath) {
+			aliases[importPath] = a
+		}
+	}
+	return paths, aliases
+}
+
+func isTypePathChar(r rune) bool {
+	return r == '_' || r == '.' || r == '/' || r == '-' || r == '~' || r > unicode.MaxASCII ||
+		'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9'
+}
@@ -74,3 +74,30 @@ func TestInferURLPath(t *testing.T) {
 		})
 	}
 }
+
+func TestIsIdempotent(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   Method
+		expected bool
+	}{
+		{"Get", Method{Name: "GetUser", HTTPMethod: "GET"}, true},
+		{"List", Method{Name: "ListUsers", HTTPMethod: "GET"}, true},
+		{"Update", Method{Name: "UpdateUser", HTTPMethod: "GET"}, true},
+		{"Put", Method{Name: "PutUser", HTTPMethod: "PUT"}, true},
+		{"Remove", Method{Name: "RemoveUser", HTTPMethod: "GET"}, true},
+		{"Create", Method{Name: "CreateUser", HTTPMethod: "GET"}, false},
+		{"DefaultGET", Method{Name: "SendEmail", HTTPMethod: "GET"}, false},
+		{"Directive", Method{Name: "SendEmail", HTTPMethod: "GET", Directives: []string{"idempotent"}}, true},
+		{"NonIdempotentDirective", Method{Name: "GetToken", HTTPMethod: "GET", Directives: []string{"nonidempotent"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := isIdempotent(tt.method)
+			if result != tt.expected {
+				t.Errorf("got %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// JSONSchema is the subset of JSON Schema describing the JSON encoding of Go types.
+type JSONSchema struct {
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	Nullable             bool                   `json:"-"` // Also accepts null, the type is encoded as [Type, "null"]
+}
+
+// MarshalJSON encodes the type of nullable schemas as a list of types.
+func (s JSONSchema) MarshalJSON() ([]byte, error) {
+	type schema JSONSchema
+	out := struct {
+		Type any `json:"type,omitempty"`
+		schema
+	}{schema: schema(s)}
+	if s.Type != "" {
+		out.Type = s.Type
+		if s.Nullable {
+			out.Type = []string{s.Type, "null"}
+		}
+	}
+	return json.Marshal(out)
+}
+
+// MCPTool is a method exposed as a Model Context Protocol tool, as returned by the mcpTools template function.
+type MCPTool struct {
+	Name         string
+	Description  string
+	InputSchema  string // JSON encoded schema of the arguments, an object with a property per param
+	OutputSchema string // JSON encoded schema of the structured result, empty when the method returns nothing
+	Required     []string
+	Method       Method
+}
+
+// jsonSchemaBuilder derives schemas from the type strings used in templates.
+type jsonSchemaBuilder struct {
+	im       *importer
+	defs     map[string]TypeDef // Named types by their qualified Go type
+	visiting map[string]bool
+}
+
+func newJSONSchemaBuilder(im *importer, types []TypeDef) *jsonSchemaBuilder {
+	b := &jsonSchemaBuilder{im: im, defs: map[string]TypeDef{}, visiting: map[string]bool{}}
+	for _, def := range types {
+		b.defs[im.qualify(def.Name)] = def
+	}
+	return b
+}
+
+// buildMCPTools returns a tool for every method of the interface rendered with r.
+// Params without a pointer or variadic type are required arguments.
+func buildMCPTools(im *importer, r TemplateReplace) []MCPTool {
+	b := newJSONSchemaBuilder(im, r.Types)
+	var tools []MCPTool
+	for _, m := range r.Methods {
+		tool := MCPTool{
+			Name:        m.Name,
+			Description: orFunc(m.Doc, fmt.Sprintf("Calls %s.%s", r.InterfaceName, m.Name)),
+			Method:      m,
+		}
+		input := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}}
+		for _, p := range m.Params {
+			input.Properties[p.Name] = b.schema(p.Type)
+			if !isPointerType(p.Type) && !isVariadicType(p.Type) {
+				tool.Required = append(tool.Required, p.Name)
+			}
+		}
+		input.Required = tool.Required
+		tool.InputSchema = schemaJSON(input)
+
+		if returns := nonErrorReturns(m.Returns); len(returns) > 0 {
+			output := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}}
+			names := returnNames(m.Returns)
+			for i, ret := range m.Returns {
+				if ret.Type != "error" {
+					output.Properties[names[i]] = b.schema(ret.Type)
+				}
+			}
+			tool.OutputSchema = schemaJSON(output)
+		}
+		tools = append(tools, tool)
+	}
+	return tools
+}
+
+// schema returns the schema of the JSON encoding of the Go type t.
+// Types without a fixed shape, and recursive references, accept any value.
+// Pointers, slices and maps are nullable as their nil values encode to null.
+func (b *jsonSchemaBuilder) schema(t string) *JSONSchema {
+	if isVariadicType(t) {
+		t = "[]" + elemType(t)
+	}
+	switch t {
+	case "bool":
+		return &JSONSchema{Type: "boolean"}
+	case "string":
+		return &JSONSchema{Type: "string"}
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte", "rune":
+		return &JSONSchema{Type: "integer"}
+	case "float32", "float64":
+		return &JSONSchema{Type: "number"}
+	case "[]byte", "[]uint8":
+		return &JSONSchema{Type: "string", Format: "byte", Description: "base64 encoded", Nullable: true}
+	case "time.Time":
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case "time.Duration":
+		return &JSONSchema{Type: "integer", Description: "nanoseconds"}
+	}
+
+	switch {
+	case isPointerType(t):
+		s := b.schema(elemType(t))
+		s.Nullable = true
+		return s
+	case isSliceType(t):
+		return &JSONSchema{Type: "array", Items: b.schema(elemType(t)), Nullable: true}
+	case isMapType(t):
+		return &JSONSchema{Type: "object", AdditionalProperties: b.schema(elemType(t)), Nullable: true}
+	}
+
+	def, ok := b.defs[t]
+	if !ok || b.visiting[t] {
+		return &JSONSchema{}
+	}
+	b.visiting[t] = true
+	defer delete(b.visiting, t)
+	switch def.Kind {
+	case "struct":
+		s := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}}
+		for _, f := range def.Fields {
+			s.Properties[f.JSONName] = b.schema(b.im.qualify(f.Type))
+		}
+		return s
+	case "interface":
+		return &JSONSchema{}
+	default:
+		return b.schema(b.im.qualify(def.Underlying))
+	}
+}
+
+// schemaJSON encodes a schema, which can not fail
+func schemaJSON(s *JSONSchema) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
@@ -0,0 +1,73 @@
+package parser
+
+import "testing"
+
+func TestMCPTools(t *testing.T) {
+	im := newImporter(nil)
+	im.reserve("example.com/app/users", "users")
+	tools := buildMCPTools(im, TemplateReplace{
+		PackageName:   "users",
+		InterfaceName: "UserService",
+		Methods: []Method{{
+			Name:    "Search",
+			Doc:     "Search finds users.",
+			Params:  []Param{{Name: "query", Type: "string"}, {Name: "since", Type: "*time.Time"}, {Name: "roles", Type: "...users.Role"}},
+			Returns: []Return{{Type: "[]users.User"}, {Type: "int"}, {Type: "error"}},
+		}, {
+			Name:    "Delete",
+			Params:  []Param{{Name: "data", Type: "[]byte"}},
+			Returns: []Return{{Type: "error"}},
+		}, {
+			Name:    "Label",
+			Params:  []Param{{Name: "id", Type: "*int"}, {Name: "labels", Type: "map[string]string"}, {Name: "owner", Type: "*users.User"}},
+			Returns: []Return{{Type: "*users.Role"}},
+		}},
+		Types: []TypeDef{
+			{Name: "example.com/app/users.Role", Kind: "basic", Underlying: "string"},
+			{Name: "example.com/app/users.User", Kind: "struct", Fields: []Field{
+				{Name: "ID", Type: "string", JSONName: "id"},
+				{Name: "Friends", Type: "[]*example.com/app/users.User", JSONName: "friends"},
+				{Name: "Scores", Type: "map[string]float64", JSONName: "scores"},
+			}},
+		},
+	})
+
+	tests := []struct {
+		tool         MCPTool
+		description  string
+		inputSchema  string
+		outputSchema string
+	}{
+		{
+			tools[0],
+			"Search finds users.",
+			`{"type":"object","properties":{"query":{"type":"string"},"roles":{"type":["array","null"],"items":{"type":"string"}},"since":{"type":["string","null"],"format":"date-time"}},"required":["query"]}`,
+			`{"type":"object","properties":{"result0":{"type":["array","null"],"items":{"type":"object","properties":{"friends":{"type":["array","null"],"items":{}},"id":{"type":"string"},"scores":{"type":["object","null"],"additionalProperties":{"type":"number"}}}}},"result1":{"type":"integer"}}}`,
+		},
+		{
+			tools[1],
+			"Calls UserService.Delete",
+			`{"type":"object","properties":{"data":{"type":["string","null"],"format":"byte","description":"base64 encoded"}},"required":["data"]}`,
+			"",
+		},
+		{
+			tools[2],
+			"Calls UserService.Label",
+			`{"type":"object","properties":{"id":{"type":["integer","null"]},"labels":{"type":["object","null"],"additionalProperties":{"type":"string"}},"owner":{"type":["object","null"],"properties":{"friends":{"type":["array","null"],"items":{}},"id":{"type":"string"},"scores":{"type":["object","null"],"additionalProperties":{"type":"number"}}}}},"required":["labels"]}`,
+			`{"type":"object","properties":{"result":{"type":["string","null"]}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool.Name, func(t *testing.T) {
+			if tt.tool.Description != tt.description {
+				t.Errorf("got description %q, want %q", tt.tool.Description, tt.description)
+			}
+			if tt.tool.InputSchema != tt.inputSchema {
+				t.Errorf("got input schema %s, want %s", tt.tool.InputSchema, tt.inputSchema)
+			}
+			if tt.tool.OutputSchema != tt.outputSchema {
+				t.Errorf("got output schema %s, want %s", tt.tool.OutputSchema, tt.outputSchema)
+			}
+		})
+	}
+}
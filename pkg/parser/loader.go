@@ -0,0 +1,458 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Seann-Moser/go-serve/pkg/ctxLogger"
+	"go.uber.org/zap"
+	"golang.org/x/tools/go/packages"
+)
+
+// loadMode loads syntax and type information for the selected packages and
+// their dependencies. Dependencies are shared across every package in a load.
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes |
+	packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps | packages.NeedModule
+
+// Packages holds every package selected for generation. All packages share one FileSet.
+type Packages struct {
+	Fset   *token.FileSet
+	byFile map[string]*packages.Package
+	byDir  map[string]*packages.Package
+	byPath map[string]*packages.Package
+	names  map[string]string // import path -> package name, for every package that was seen
+}
+
+// LoadPackages loads the packages of every FileInterface with a single packages.Load
+// per module, or per go.work workspace for the modules of a workspace.
+func LoadPackages(ctx context.Context, gofiles []FileInterface) (*Packages, error) {
+	return loadPackages(ctx, gofiles, nil, loadOptions{})
+}
+
+// loadOptions adjust how loadPackages reads packages.
+type loadOptions struct {
+	overlay    map[string][]byte // Files read from memory instead of disk
+	typeErrors bool              // Type errors are warnings, the types of the packages are still used
+	fset       *token.FileSet    // Shared with packages loaded before, a new one when nil
+}
+
+// newPackages returns an empty set of packages positioned in fset.
+func newPackages(fset *token.FileSet) *Packages {
+	if fset == nil {
+		fset = token.NewFileSet()
+	}
+	return &Packages{
+		Fset:   fset,
+		byFile: map[string]*packages.Package{},
+		byDir:  map[string]*packages.Package{},
+		byPath: map[string]*packages.Package{},
+		names:  map[string]string{},
+	}
+}
+
+func loadPackages(ctx context.Context, gofiles []FileInterface, diags *diagnostics, opts loadOptions) (*Packages, error) {
+	loaded := newPackages(opts.fset)
+
+	// Group the package patterns by the module and build configuration they are loaded with
+	type loadKey struct {
+		dir    string
+		goWork string
+		tags   string
+		tests  bool
+	}
+	patterns := map[loadKey][]string{}
+	seen := map[loadKey]map[string]bool{}
+	for _, i := range gofiles {
+		key := loadKey{dir: i.ModuleDir, goWork: i.GoWork, tags: strings.Join(i.BuildTags, ","), tests: strings.HasSuffix(i.FilePath, "_test.go")}
+		switch {
+		case key.goWork != "" && key.goWork != "off":
+			key.dir = filepath.Dir(key.goWork)
+		case key.dir == "":
+			key.dir = i.Dir
+		}
+		pattern := i.ImportName
+		if i.Dir != "" {
+			pattern = i.Dir
+		}
+		if seen[key] == nil {
+			seen[key] = map[string]bool{}
+		}
+		if seen[key][pattern] {
+			continue
+		}
+		seen[key][pattern] = true
+		patterns[key] = append(patterns[key], pattern)
+	}
+	keys := make([]loadKey, 0, len(patterns))
+	for key := range patterns {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].dir != keys[j].dir {
+			return keys[i].dir < keys[j].dir
+		}
+		if keys[i].goWork != keys[j].goWork {
+			return keys[i].goWork < keys[j].goWork
+		}
+		if keys[i].tags != keys[j].tags {
+			return keys[i].tags < keys[j].tags
+		}
+		return !keys[i].tests && keys[j].tests
+	})
+
+	for _, key := range keys {
+		ctxLogger.Info(ctx, "Loading packages", zap.String("dir", key.dir), zap.Int("count", len(patterns[key])), zap.String("tags", key.tags))
+		cfg := &packages.Config{
+			Context: ctx,
+			Mode:    loadMode,
+			Fset:    loaded.Fset,
+			Dir:     key.dir,
+			Env:     os.Environ(),
+			Tests:   key.tests,
+			Overlay: opts.overlay,
+		}
+		if key.tags != "" {
+			cfg.BuildFlags = []string{"-tags=" + key.tags}
+		}
+		if key.goWork != "" {
+			cfg.Env = append(cfg.Env, "GOWORK="+key.goWork)
+		}
+		pkgs, err := packages.Load(cfg, patterns[key]...)
+		if err != nil {
+			ctxLogger.Error(ctx, "Failed to load packages", zap.Error(err))
+			return nil, fmt.Errorf("failed to load packages: %w", err)
+		}
+		if reportPackageErrors(ctx, pkgs, diags, opts.typeErrors) > 0 {
+			return nil, fmt.Errorf("errors occurred while loading packages")
+		}
+		for _, p := range pkgs {
+			loaded.add(p)
+		}
+	}
+	return loaded, nil
+}
+
+// reportPackageErrors logs and records the errors of pkgs and their dependencies, and returns how many there were.
+// Type errors are only warnings, and not counted, when typeErrors is set.
+func reportPackageErrors(ctx context.Context, pkgs []*packages.Package, diags *diagnostics, typeErrors bool) int {
+	var count int
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, e := range pkg.Errors {
+			if typeErrors && e.Kind == packages.TypeError {
+				ctxLogger.Warn(ctx, "Package has type errors", zap.String("package", pkg.PkgPath), zap.String("pos", e.Pos), zap.String("error", e.Msg))
+				diags.add(Diagnostic{Severity: SeverityWarning, Pos: e.Pos, Message: e.Msg})
+				continue
+			}
+			count++
+			ctxLogger.Error(ctx, "Failed to load package", zap.String("package", pkg.PkgPath), zap.String("pos", e.Pos), zap.String("error", e.Msg))
+			diags.add(Diagnostic{Severity: SeverityError, Pos: e.Pos, Message: e.Msg})
+		}
+	})
+	return count
+}
+
+// add indexes p. Test variants of a package only replace it for the test files.
+func (p *Packages) add(pkg *packages.Package) {
+	isVariant := pkg.ID != pkg.PkgPath
+	if !isVariant || p.byPath[pkg.PkgPath] == nil {
+		p.byPath[pkg.PkgPath] = pkg
+	}
+	for _, file := range pkg.GoFiles {
+		if existing, ok := p.byFile[file]; !ok || !isVariant && existing.ID != existing.PkgPath {
+			p.byFile[file] = pkg
+		}
+	}
+	if len(pkg.GoFiles) > 0 && (!isVariant || p.byDir[filepath.Dir(pkg.GoFiles[0])] == nil) {
+		p.byDir[filepath.Dir(pkg.GoFiles[0])] = pkg
+	}
+	if pkg.Types != nil {
+		addPackageNames(p.names, pkg.Types)
+	}
+}
+
+// addPackageNames records the name of pkg and of every package it imports.
+func addPackageNames(names map[string]string, pkg *types.Package) {
+	if _, ok := names[pkg.Path()]; ok {
+		return
+	}
+	names[pkg.Path()] = pkg.Name()
+	for _, imported := range pkg.Imports() {
+		addPackageNames(names, imported)
+	}
+}
+
+// Lookup returns the loaded package of i.
+func (p *Packages) Lookup(i FileInterface) (*packages.Package, error) {
+	if pkg, ok := p.byFile[filepath.Join(i.Dir, filepath.Base(i.FilePath))]; ok && i.Dir != "" {
+		return pkg, nil
+	}
+	if pkg, ok := p.byDir[i.Dir]; ok && i.Dir != "" {
+		return pkg, nil
+	}
+	if pkg, ok := p.byPath[i.ImportName]; ok {
+		return pkg, nil
+	}
+	return nil, fmt.Errorf("could not find package containing %s", i.FilePath)
+}
+
+// localImports returns the directories of the packages of the main modules that pkg imports, directly or not.
+func localImports(pkg *packages.Package) map[string]bool {
+	dirs := map[string]bool{}
+	seen := map[string]bool{}
+	var visit func(p *packages.Package)
+	visit = func(p *packages.Package) {
+		for _, imported := range p.Imports {
+			if seen[imported.ID] {
+				continue
+			}
+			seen[imported.ID] = true
+			if imported.Module != nil && imported.Module.Main && len(imported.GoFiles) > 0 {
+				dirs[filepath.Dir(imported.GoFiles[0])] = true
+			}
+			visit(imported)
+		}
+	}
+	visit(pkg)
+	return dirs
+}
+
+// RenderJob renders the interfaces of one FileInterface with one set of options.
+type RenderJob struct {
+	File    FileInterface
+	Options RenderOptions
+}
+
+// RenderJobs loads the packages of every job in one pass and renders the interfaces in
+// parallel. The files are returned in job order, so the output does not depend on scheduling.
+func RenderJobs(ctx context.Context, jobs []RenderJob) ([]GeneratedFile, error) {
+	perJob, err := renderJobs(ctx, jobs, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result []GeneratedFile
+	for _, files := range perJob {
+		result = append(result, files...)
+	}
+	return result, nil
+}
+
+// renderJobs is RenderJobs with the files grouped by job. Problems that do not stop
+// the run are recorded in diags.
+func renderJobs(ctx context.Context, jobs []RenderJob, diags *diagnostics) ([][]GeneratedFile, error) {
+	gofiles := make([]FileInterface, len(jobs))
+	for j := range jobs {
+		gofiles[j] = jobs[j].File
+	}
+	loaded, err := loadPackages(ctx, gofiles, diags, loadOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return renderLoadedJobs(ctx, loaded, jobs, diags)
+}
+
+// renderLoadedJobs is renderJobs with the packages of the jobs already loaded.
+func renderLoadedJobs(ctx context.Context, loaded *Packages, jobs []RenderJob, diags *diagnostics) ([][]GeneratedFile, error) {
+	jobs = slices.Clone(jobs)
+	kinds := make([]generatorKind, len(jobs))
+	for j := range jobs {
+		opts, kind, err := normalizeRenderOptions(jobs[j].Options)
+		if err != nil {
+			return nil, err
+		}
+		jobs[j].Options, kinds[j] = opts, kind
+	}
+
+	type task struct {
+		job  int
+		name string
+	}
+	var tasks []task
+	for j, job := range jobs {
+		if kinds[j].Plugin != "" {
+			continue
+		}
+		for _, name := range job.File.Interfaces {
+			tasks = append(tasks, task{job: j, name: name})
+		}
+	}
+
+	files := make([]*GeneratedFile, len(tasks))
+	errs := make([]error, len(tasks))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for t := range tasks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			job := jobs[tasks[t].job]
+			files[t], errs[t] = renderLoadedInterface(ctx, loaded, job.File, tasks[t].name, kinds[tasks[t].job], job.Options, diags)
+		}(t)
+	}
+	wg.Wait()
+
+	result := make([][]GeneratedFile, len(jobs))
+	for t, f := range files {
+		if errs[t] != nil {
+			return nil, errs[t]
+		}
+		if f != nil {
+			result[tasks[t].job] = append(result[tasks[t].job], *f)
+		}
+	}
+	if err := renderPlugins(ctx, loaded, jobs, kinds, result, diags); err != nil {
+		return nil, err
+	}
+
+	owners := map[string]string{}
+	for j := range result {
+		for _, f := range result[j] {
+			owner := f.Interface
+			if owner == "" {
+				owner = jobs[j].Options.Kind
+			}
+			if previous, ok := owners[f.Path]; ok {
+				return nil, fmt.Errorf("%s is generated for both %s and %s", f.Path, previous, owner)
+			}
+			owners[f.Path] = owner
+		}
+	}
+	return result, nil
+}
+
+// Interface is the resolved model of a selected interface.
+type Interface struct {
+	Name        string        `json:"name" yaml:"name"`
+	Doc         string        `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Target      string        `json:"target,omitempty" yaml:"target,omitempty"`
+	Kind        string        `json:"kind" yaml:"kind"` // Generator kind of the target
+	PackageName string        `json:"packageName" yaml:"packageName"`
+	ImportPath  string        `json:"importPath" yaml:"importPath"`
+	File        FileInterface `json:"file" yaml:"file"`
+	Methods     []Method      `json:"methods" yaml:"methods"`
+	Types       []TypeDef     `json:"types" yaml:"types"` // Named types used by the methods
+}
+
+// loadInterfaces resolves the model of every interface of the jobs, loading all packages in one pass.
+// targets holds the target name of every job.
+func loadInterfaces(ctx context.Context, jobs []RenderJob, targets []string, diags *diagnostics) ([]Interface, error) {
+	gofiles := make([]FileInterface, len(jobs))
+	for j := range jobs {
+		gofiles[j] = jobs[j].File
+	}
+	loaded, err := loadPackages(ctx, gofiles, diags, loadOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Interface
+	for j, job := range jobs {
+		pkg, err := loaded.Lookup(job.File)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range job.File.Interfaces {
+			i, ok := resolveInterface(ctx, pkg, job.File, name, job.Options, diags)
+			if !ok {
+				continue
+			}
+			i.Target = targets[j]
+			result = append(result, i)
+		}
+	}
+	return result, nil
+}
+
+// resolveInterface builds the model of an interface of pkg, it returns false when the interface can not be found.
+func resolveInterface(ctx context.Context, pkg *packages.Package, file FileInterface, name string, opts RenderOptions, diags *diagnostics) (Interface, bool) {
+	interfaceSource, doc := findInterface(pkg, name)
+	if interfaceSource == nil {
+		diags.addf(SeverityWarning, name, "interface not found in package %s", pkg.PkgPath)
+		return Interface{}, false
+	}
+	i := Interface{
+		Name:        name,
+		Doc:         doc,
+		Kind:        opts.Kind,
+		PackageName: pkg.Name,
+		ImportPath:  pkg.PkgPath,
+		File:        file,
+		Types:       interfaceTypes(interfaceSource, pkg.TypesInfo),
+	}
+	for _, m := range getMethods(ctx, routePrefix(opts.RoutePrefix, name), opts.PathParams, interfaceSource, pkg.TypesInfo) {
+		i.Methods = append(i.Methods, *m)
+	}
+	return i, true
+}
+
+// renderPlugins runs every plugin job. The jobs of a plugin that share their options are sent to it
+// in one request, and the files it returns are attributed to the first of those jobs.
+func renderPlugins(ctx context.Context, loaded *Packages, jobs []RenderJob, kinds []generatorKind, result [][]GeneratedFile, diags *diagnostics) error {
+	groups := map[string][]int{}
+	var order []string
+	for j, job := range jobs {
+		if kinds[j].Plugin == "" {
+			continue
+		}
+		opts := job.Options
+		key := fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%t\x00%v", opts.Kind, opts.OutputDir, opts.PackageName, opts.RoutePrefix, opts.PathParams, opts.Options)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], j)
+	}
+
+	for _, key := range order {
+		first := groups[key][0]
+		var interfaces []Interface
+		for _, j := range groups[key] {
+			pkg, err := loaded.Lookup(jobs[j].File)
+			if err != nil {
+				return err
+			}
+			for _, name := range jobs[j].File.Interfaces {
+				if i, ok := resolveInterface(ctx, pkg, jobs[j].File, name, jobs[j].Options, diags); ok {
+					interfaces = append(interfaces, i)
+				}
+			}
+		}
+		files, err := runPluginGenerator(ctx, kinds[first], jobs[first].Options, interfaces, diags)
+		if err != nil {
+			return err
+		}
+		result[first] = append(result[first], files...)
+	}
+	return nil
+}
+
+// renderLoadedInterface renders a single interface from the loaded packages,
+// it returns nil when the interface can not be found.
+func renderLoadedInterface(ctx context.Context, loaded *Packages, i FileInterface, name string, kind generatorKind, opts RenderOptions, diags *diagnostics) (*GeneratedFile, error) {
+	pkg, err := loaded.Lookup(i)
+	if err != nil {
+		return nil, err
+	}
+	model, ok := resolveInterface(ctx, pkg, i, name, opts, diags)
+	if !ok {
+		ctxLogger.Error(ctx, "Failed to parse interface source", zap.String("interface", name))
+		return nil, nil
+	}
+	ctxLogger.Info(ctx, "Found methods", zap.String("interface", name), zap.Int("count", len(model.Methods)))
+	ctxLogger.Debug(ctx, "Methods", zap.Any("methods", model.Methods))
+
+	file, err := renderInterface(ctx, loaded.names, model, kind, opts, diags)
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderFilesSingleLoad(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "pkg", "users", "users.go"), "package users\n\nimport \"context\"\n\ntype UserService interface {\n\tGetUser(ctx context.Context, id string) (string, error)\n}\n")
+	writeTestFile(t, filepath.Join(root, "pkg", "orders", "orders.go"), "package orders\n\ntype OrderService interface {\n\tListOrders() ([]string, error)\n}\n\ntype Counter interface {\n\tCount() int\n}\n")
+
+	gofiles, err := FindGoFilesWithInterfaces(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(root, "out")
+	files, err := RenderFiles(context.Background(), gofiles, RenderOptions{Kind: "handler", OutputDir: out})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	expected := []string{
+		filepath.Join(out, "order_service_handlers.go"),
+		filepath.Join(out, "counter_handlers.go"),
+		filepath.Join(out, "user_service_handlers.go"),
+	}
+	if len(paths) != len(expected) {
+		t.Fatalf("got %v, want %v", paths, expected)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("got %v, want %v", paths, expected)
+			break
+		}
+	}
+
+	// Rendering the same interface twice into one directory is an error
+	_, err = RenderJobs(context.Background(), []RenderJob{
+		{File: gofiles[0], Options: RenderOptions{OutputDir: out}},
+		{File: gofiles[0], Options: RenderOptions{OutputDir: out}},
+	})
+	if err == nil {
+		t.Error("expected a duplicate output error")
+	}
+}
@@ -1,29 +1,49 @@
 package parser
 
 type Method struct {
-	Name         string
-	HTTPMethod   string
-	HandlerName  string
-	URLPath      string
-	Params       []Param
-	Returns      []Return
-	QueryParams  []string
-	ResponseType string
-	RequestType  string
-	HasContext   bool
+	Name         string   `json:"name" yaml:"name"`
+	Doc          string   `json:"doc,omitempty" yaml:"doc,omitempty"`
+	HTTPMethod   string   `json:"httpMethod" yaml:"httpMethod"`
+	HandlerName  string   `json:"handlerName" yaml:"handlerName"`
+	URLPath      string   `json:"urlPath" yaml:"urlPath"`
+	Params       []Param  `json:"params" yaml:"params"`
+	Returns      []Return `json:"returns" yaml:"returns"`
+	QueryParams  []string `json:"queryParams" yaml:"queryParams"`
+	ResponseType string   `json:"responseType" yaml:"responseType"`
+	RequestType  string   `json:"requestType" yaml:"requestType"`
+	HasContext   bool     `json:"hasContext" yaml:"hasContext"`
+	Directives   []string `json:"directives,omitempty" yaml:"directives,omitempty"` // //interfacery: comments of the method, //interfacery:idempotent -> idempotent
 }
 
 type Param struct {
-	Name      string
-	Type      string
-	Package   string
-	IsPointer bool
-	IsElipse  bool
+	Name      string `json:"name" yaml:"name"`
+	Type      string `json:"type" yaml:"type"`
+	Package   string `json:"package,omitempty" yaml:"package,omitempty"`
+	IsPointer bool   `json:"isPointer" yaml:"isPointer"`
+	IsElipse  bool   `json:"isVariadic" yaml:"isVariadic"`
+	Binding   string `json:"binding" yaml:"binding"` // Where the HTTP handler reads the param from: "query" or "path"
 }
 
 type Return struct {
-	Type      string
-	Package   string
-	IsPointer bool
-	IsElipse  bool
+	Type      string `json:"type" yaml:"type"`
+	Package   string `json:"package,omitempty" yaml:"package,omitempty"`
+	IsPointer bool   `json:"isPointer" yaml:"isPointer"`
+	IsElipse  bool   `json:"isVariadic" yaml:"isVariadic"`
+}
+
+// TypeDef describes a named type referenced by the methods of an interface.
+type TypeDef struct {
+	Name       string  `json:"name" yaml:"name"`                                 // Qualified name, as used in Param.Type and Return.Type
+	Package    string  `json:"package" yaml:"package"`                           // Import path
+	Kind       string  `json:"kind" yaml:"kind"`                                 // Kind of the underlying type: struct, interface, basic, slice, map, ...
+	Underlying string  `json:"underlying,omitempty" yaml:"underlying,omitempty"` // Underlying type when Kind is not struct
+	Fields     []Field `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// Field is an exported field of a struct TypeDef. Fields of embedded structs are promoted.
+type Field struct {
+	Name      string `json:"name" yaml:"name"`
+	Type      string `json:"type" yaml:"type"`
+	JSONName  string `json:"jsonName" yaml:"jsonName"` // Key in the JSON encoding
+	OmitEmpty bool   `json:"omitEmpty" yaml:"omitEmpty"`
 }
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Module is the Go module a package directory belongs to.
+type Module struct {
+	Path string // Module path declared in go.mod
+	Dir  string // Directory containing go.mod
+	// GoWork is the go.work file of the workspace using the module, so the packages of
+	// every module of the workspace load together. It is "off" when a go.work file
+	// applies to the directory without using the module, and empty without one.
+	GoWork string
+}
+
+// moduleResolver resolves import paths from the nearest go.mod, so nested
+// modules and modules outside GOPATH all resolve the same way regardless of
+// the working directory, and finds the go.work workspace of every module the
+// way the go command does. Lookups are cached per directory.
+type moduleResolver struct {
+	modules    map[string]*Module
+	workspaces map[string]map[string]bool // go.work file -> directories of the modules it uses
+}
+
+func newModuleResolver() *moduleResolver {
+	return &moduleResolver{modules: map[string]*Module{}, workspaces: map[string]map[string]bool{}}
+}
+
+// module returns the module of dir, or nil when dir is not inside a module.
+func (r *moduleResolver) module(dir string) (*Module, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := r.modules[dir]; ok {
+		return m, nil
+	}
+
+	var m *Module
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	switch {
+	case err == nil:
+		modulePath := modfile.ModulePath(data)
+		if modulePath == "" {
+			return nil, fmt.Errorf("%s has no module directive", filepath.Join(dir, "go.mod"))
+		}
+		m = &Module{Path: modulePath, Dir: dir}
+		if m.GoWork, err = r.goWork(dir); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		if parent := filepath.Dir(dir); parent != dir {
+			if m, err = r.module(parent); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, err
+	}
+	r.modules[dir] = m
+	return m, nil
+}
+
+// goWork returns the go.work file applying to the module in dir, from GOWORK or the nearest
+// parent directory, or "off" when the go.work file does not use the module.
+func (r *moduleResolver) goWork(dir string) (string, error) {
+	workPath := os.Getenv("GOWORK")
+	switch {
+	case workPath == "off":
+		return "", nil
+	case workPath == "":
+		for d := dir; ; d = filepath.Dir(d) {
+			if _, err := os.Stat(filepath.Join(d, "go.work")); err == nil {
+				workPath = filepath.Join(d, "go.work")
+				break
+			}
+			if filepath.Dir(d) == d {
+				return "", nil
+			}
+		}
+	}
+
+	used, ok := r.workspaces[workPath]
+	if !ok {
+		data, err := os.ReadFile(workPath)
+		if err != nil {
+			return "", err
+		}
+		work, err := modfile.ParseWork(workPath, data, nil)
+		if err != nil {
+			return "", err
+		}
+		used = map[string]bool{}
+		for _, use := range work.Use {
+			useDir := filepath.FromSlash(use.Path)
+			if !filepath.IsAbs(useDir) {
+				useDir = filepath.Join(filepath.Dir(workPath), useDir)
+			}
+			used[filepath.Clean(useDir)] = true
+		}
+		r.workspaces[workPath] = used
+	}
+	if !used[dir] {
+		return "off", nil
+	}
+	return workPath, nil
+}
+
+// importPath returns the import path of the package in dir and the module it belongs to.
+// Outside a module the import path is resolved against GOPATH.
+func (r *moduleResolver) importPath(dir string) (string, *Module, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	m, err := r.module(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	if m != nil {
+		rel, err := filepath.Rel(m.Dir, dir)
+		if err != nil {
+			return "", nil, err
+		}
+		return path.Join(m.Path, filepath.ToSlash(rel)), m, nil
+	}
+
+	for _, gopath := range filepath.SplitList(build.Default.GOPATH) {
+		src := filepath.Join(gopath, "src") + string(filepath.Separator)
+		if strings.HasPrefix(dir, src) {
+			return filepath.ToSlash(strings.TrimPrefix(dir, src)), nil, nil
+		}
+	}
+	return "", nil, fmt.Errorf("%s is not inside a Go module or GOPATH", dir)
+}
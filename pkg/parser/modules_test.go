@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, filePath, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestModuleResolverImportPath(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "tools", "go.mod"), "module example.com/tools\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "bad", "go.mod"), "go 1.23\n")
+
+	tests := []struct {
+		name      string
+		dir       string
+		expected  string
+		moduleDir string
+		wantErr   bool
+	}{
+		{"ModuleRoot", root, "example.com/root", root, false},
+		{"Subdirectory", filepath.Join(root, "pkg", "users"), "example.com/root/pkg/users", root, false},
+		{"NestedModule", filepath.Join(root, "tools", "gen"), "example.com/tools/gen", filepath.Join(root, "tools"), false},
+		{"MissingModuleDirective", filepath.Join(root, "bad", "x"), "", "", true},
+	}
+
+	r := newModuleResolver()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			importPath, module, err := r.importPath(tt.dir)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if importPath != tt.expected {
+				t.Errorf("got %v, want %v", importPath, tt.expected)
+			}
+			if module.Dir != tt.moduleDir {
+				t.Errorf("got module dir %v, want %v", module.Dir, tt.moduleDir)
+			}
+		})
+	}
+}
+
+func TestFindGoFilesWithInterfacesImportName(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	// The file is not named after its package
+	writeTestFile(t, filepath.Join(root, "pkg", "users", "service.go"), "package users\n\ntype UserService interface {\n\tGet(id string) error\n}\n")
+	writeTestFile(t, filepath.Join(root, "tools", "go.mod"), "module example.com/tools\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "tools", "tools.go"), "package tools\n\ntype Tool interface {\n\tRun() error\n}\n")
+
+	result, err := FindGoFilesWithInterfaces(filepath.Join(root, "pkg"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0].ImportName != "example.com/root/pkg/users" || result[0].ModuleDir != root {
+		t.Errorf("got %+v, want example.com/root/pkg/users", result)
+	}
+
+	result, err = FindGoFilesWithInterfaces(root, "Tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0].ImportName != "example.com/tools" {
+		t.Errorf("got %+v, want example.com/tools", result)
+	}
+}
+
+func TestModuleResolverWorkspace(t *testing.T) {
+	t.Setenv("GOWORK", "")
+	// -mod=mod is rejected in workspace mode
+	t.Setenv("GOFLAGS", "")
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.work"), "go 1.23\n\nuse (\n\t./api\n\t./models\n)\n")
+	writeTestFile(t, filepath.Join(root, "models", "go.mod"), "module example.com/models\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "models", "models.go"), "package models\n\ntype User struct {\n\tName string\n}\n")
+	writeTestFile(t, filepath.Join(root, "api", "go.mod"), "module example.com/api\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "api", "api.go"), "package api\n\nimport \"example.com/models\"\n\ntype UserService interface {\n\tGetUser(id string) (*models.User, error)\n}\n")
+	// tools is below the go.work file without being one of its modules
+	writeTestFile(t, filepath.Join(root, "tools", "go.mod"), "module example.com/tools\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "tools", "tools.go"), "package tools\n\ntype Tool interface {\n\tRun(name string) error\n}\n")
+
+	gofiles, err := FindGoFilesWithInterfaces(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goWorks := map[string]string{}
+	for _, gofile := range gofiles {
+		goWorks[gofile.ImportName] = gofile.GoWork
+	}
+	expected := map[string]string{"example.com/api": filepath.Join(root, "go.work"), "example.com/tools": "off"}
+	if !reflect.DeepEqual(goWorks, expected) {
+		t.Fatalf("got %v, want %v", goWorks, expected)
+	}
+
+	// The modules of the workspace resolve each other, and the other module loads on its own
+	files, err := RenderFiles(context.Background(), gofiles, RenderOptions{Kind: "proto", OutputDir: filepath.Join(root, "out")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || !strings.Contains(string(files[0].Content), "string name = 1;") || !strings.Contains(string(files[1].Content), "rpc Run") {
+		t.Errorf("got %d files, want the UserService and Tool services:\n%v", len(files), files)
+	}
+}
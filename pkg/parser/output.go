@@ -0,0 +1,257 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// GeneratedFile is a rendered output that has not been written to disk yet.
+type GeneratedFile struct {
+	Path      string
+	Content   []byte
+	Interface string // Interface the file was rendered from
+}
+
+// OutputFS is where generated files are compared with and written to.
+type OutputFS interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte) error
+}
+
+// OSFS is the OutputFS of the local disk, parent directories are created as needed.
+type OSFS struct{}
+
+func (OSFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (OSFS) WriteFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	return os.WriteFile(name, data, 0o644)
+}
+
+// MemFS is an OutputFS that keeps every file in memory. It is safe for concurrent use.
+type MemFS struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemFS returns a MemFS holding a copy of files.
+func NewMemFS(files map[string][]byte) *MemFS {
+	m := &MemFS{files: map[string][]byte{}}
+	for name, data := range files {
+		m.files[filepath.Clean(name)] = bytes.Clone(data)
+	}
+	return m
+}
+
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[filepath.Clean(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return bytes.Clone(data), nil
+}
+
+func (m *MemFS) WriteFile(name string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = map[string][]byte{}
+	}
+	m.files[filepath.Clean(name)] = bytes.Clone(data)
+	return nil
+}
+
+// Files returns a copy of every file in m.
+func (m *MemFS) Files() map[string][]byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	files := make(map[string][]byte, len(m.files))
+	for name, data := range m.files {
+		files[name] = bytes.Clone(data)
+	}
+	return files
+}
+
+// FileDiff describes a generated file whose content differs from what is on disk.
+type FileDiff struct {
+	Path    string
+	Missing bool   // File does not exist on disk
+	Diff    string // Unified diff from the on disk content to the generated content
+}
+
+// WriteGeneratedFiles writes every file, creating parent directories as needed.
+func WriteGeneratedFiles(files []GeneratedFile) error {
+	return WriteFiles(OSFS{}, files)
+}
+
+// WriteFiles writes every file to fsys.
+func WriteFiles(fsys OutputFS, files []GeneratedFile) error {
+	for _, f := range files {
+		if err := fsys.WriteFile(f.Path, f.Content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// DiffGeneratedFiles compares the generated files with the files on disk and
+// returns the ones that are missing or out of date.
+func DiffGeneratedFiles(files []GeneratedFile) ([]FileDiff, error) {
+	return DiffFiles(OSFS{}, files)
+}
+
+// DiffFiles compares the generated files with the files in fsys and
+// returns the ones that are missing or out of date.
+func DiffFiles(fsys OutputFS, files []GeneratedFile) ([]FileDiff, error) {
+	var diffs []FileDiff
+	for _, f := range files {
+		current, err := fsys.ReadFile(f.Path)
+		missing := errors.Is(err, fs.ErrNotExist)
+		if err != nil && !missing {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Path, err)
+		}
+		if !missing && bytes.Equal(current, f.Content) {
+			continue
+		}
+		diffs = append(diffs, FileDiff{
+			Path:    f.Path,
+			Missing: missing,
+			Diff:    unifiedDiff(f.Path, string(current), string(f.Content), missing),
+		})
+	}
+	return diffs, nil
+}
+
+const diffContext = 3
+
+type diffLine struct {
+	op   byte // ' ', '-' or '+'
+	text string
+}
+
+// unifiedDiff renders a unified diff between two texts.
+func unifiedDiff(name, oldText, newText string, missing bool) string {
+	oldLines := splitLines(oldText)
+	newLines := splitLines(newText)
+	lines := diffLines(oldLines, newLines)
+
+	oldName := "a/" + filepath.ToSlash(name)
+	if missing {
+		oldName = "/dev/null"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ b/%s\n", oldName, filepath.ToSlash(name))
+
+	// Group changes into hunks with diffContext lines of context on each side.
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			continue
+		}
+		hunkStart := max(start-diffContext, 0)
+		hunkEnd := start
+		for i := start; i < len(lines); i++ {
+			if lines[i].op != ' ' {
+				hunkEnd = i + 1
+				continue
+			}
+			if i-hunkEnd >= 2*diffContext {
+				break
+			}
+		}
+		hunkEnd = min(hunkEnd+diffContext, len(lines))
+
+		oldStart, newStart := 1, 1
+		for _, l := range lines[:hunkStart] {
+			if l.op != '+' {
+				oldStart++
+			}
+			if l.op != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, l := range lines[hunkStart:hunkEnd] {
+			if l.op != '+' {
+				oldCount++
+			}
+			if l.op != '-' {
+				newCount++
+			}
+		}
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, l := range lines[hunkStart:hunkEnd] {
+			b.WriteByte(l.op)
+			b.WriteString(l.text)
+			b.WriteByte('\n')
+		}
+		start = hunkEnd
+	}
+	return b.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes a line diff using the longest common subsequence.
+func diffLines(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{'+', b[j]})
+	}
+	return lines
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldText  string
+		newText  string
+		missing  bool
+		expected string
+	}{
+		{
+			name:     "Changed line",
+			oldText:  "a\nb\nc\n",
+			newText:  "a\nB\nc\n",
+			expected: "--- a/f.go\n+++ b/f.go\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:     "Missing file",
+			oldText:  "",
+			newText:  "a\nb\n",
+			missing:  true,
+			expected: "--- /dev/null\n+++ b/f.go\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:     "Separate hunks",
+			oldText:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			newText:  "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			expected: "--- a/f.go\n+++ b/f.go\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -9,4 +10,3 @@\n 9\n 10\n 11\n-12\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := unifiedDiff("f.go", tt.oldText, tt.newText, tt.missing)
+			if result != tt.expected {
+				t.Errorf("got\n%s\nwant\n%s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDiffGeneratedFiles(t *testing.T) {
+	dir := t.TempDir()
+	upToDate := filepath.Join(dir, "up_to_date.go")
+	stale := filepath.Join(dir, "stale.go")
+	if err := os.WriteFile(upToDate, []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	diffs, err := DiffGeneratedFiles([]GeneratedFile{
+		{Path: upToDate, Content: []byte("package a\n")},
+		{Path: stale, Content: []byte("package b\n")},
+		{Path: filepath.Join(dir, "missing.go"), Content: []byte("package a\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 2 {
+		t.Fatalf("got %d diffs, want 2", len(diffs))
+	}
+	if diffs[0].Path != stale || diffs[0].Missing {
+		t.Errorf("got %+v, want stale file", diffs[0])
+	}
+	if !diffs[1].Missing {
+		t.Errorf("got %+v, want missing file", diffs[1])
+	}
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"bytes"
 	"context"
 	_ "embed"
 	"fmt"
@@ -10,10 +11,10 @@ import (
 	"go/token"
 	"go/types"
 	"golang.org/x/tools/go/packages"
-	"os"
-	"os/exec"
+	"golang.org/x/tools/imports"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"unicode"
 )
@@ -27,130 +28,178 @@ type TemplateReplace struct {
 	Methods            []Method
 	ImportName         string
 	DirPackageName     string
-	Imports            map[string]bool
+	Imports            map[string]bool   // Packages referenced by the method types
+	ImportAliases      map[string]string // Aliases for the Imports whose name differs from their path
 	NeedsContextImport bool
 	NeedsStrconvImport bool
 	NeedsJSONImport    bool
 	NeedsNetHTTPImport bool
 	NeedsFmtImport     bool
+	NeedsMuxImport     bool // Some params are bound to the path, see RenderOptions.PathParams
+	InterfaceDoc       string
+	Types              []TypeDef         // Named types used by the methods, see Interface
+	Options            map[string]string // Target options from the project config
+	OutputImportPath   string            // Import path of the output directory, empty outside of a module
+}
+
+// RenderOptions controls what is rendered for a FileInterface and where it is written.
+type RenderOptions struct {
+	Kind        string // Generator kind, defaults to "handler"
+	OutputDir   string
+	PackageName string            // Package name of the generated files, defaults to the base name of OutputDir
+	Template    string            // Overrides the built-in template of the generator kind
+	Partials    map[string]string // Additional named templates, see LoadTemplateOverride
+	RoutePrefix string            // Prepended to every inferred URL path
+	PathParams  bool              // Bind the params named after the By of a method name to path segments instead of the query
+	Options     map[string]string // Generator specific options, available to templates and plugins
 }
 
 func GenerateHTTPHandlers(ctx context.Context, i FileInterface, packageName, outputDir, newTemplate string) error {
-	if newTemplate == "" {
-		newTemplate = handlerTemplate
+	files, err := RenderHTTPHandlers(ctx, i, packageName, outputDir, newTemplate)
+	if err != nil {
+		return err
 	}
+	return WriteGeneratedFiles(files)
+}
 
-	// Create a new FileSet
-	fset := token.NewFileSet()
+// RenderHTTPHandlers renders the handlers for every interface in i without writing them to disk.
+func RenderHTTPHandlers(ctx context.Context, i FileInterface, packageName, outputDir, newTemplate string) ([]GeneratedFile, error) {
+	i.PackageName = packageName
+	return Render(ctx, i, RenderOptions{Kind: "handler", OutputDir: outputDir, Template: newTemplate})
+}
 
-	// Determine the module root directory
-	moduleRootDir, err := getModuleRootDir()
-	if err != nil {
-		return fmt.Errorf("failed to get module root directory: %w", err)
-	}
+// Render renders the generator selected by opts.Kind for every interface in i.
+func Render(ctx context.Context, i FileInterface, opts RenderOptions) ([]GeneratedFile, error) {
+	return RenderJobs(ctx, []RenderJob{{File: i, Options: opts}})
+}
 
-	ctxLogger.Info(ctx, "Import path", zap.String("importPath", i.ImportName))
-	// Load the package using go/packages
-	cfg := &packages.Config{
-		Context: ctx,
-		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
-		Fset:    fset,
-		Dir:     moduleRootDir,
-		Env:     os.Environ(),
+// RenderFiles renders every FileInterface with the same options, loading all packages in one pass.
+func RenderFiles(ctx context.Context, gofiles []FileInterface, opts RenderOptions) ([]GeneratedFile, error) {
+	jobs := make([]RenderJob, 0, len(gofiles))
+	for _, gofile := range gofiles {
+		jobs = append(jobs, RenderJob{File: gofile, Options: opts})
 	}
+	return RenderJobs(ctx, jobs)
+}
 
-	pkgs, err := packages.Load(cfg, i.ImportName)
-	if err != nil {
-		ctxLogger.Error(ctx, "Failed to load packages", zap.Error(err))
-		return fmt.Errorf("failed to load packages: %w", err)
+// normalizeRenderOptions fills in the defaults of opts and checks that its template parses.
+func normalizeRenderOptions(opts RenderOptions) (RenderOptions, generatorKind, error) {
+	if opts.Kind == "" {
+		opts.Kind = "handler"
 	}
-
-	if packages.PrintErrors(pkgs) > 0 {
-		ctxLogger.Error(ctx, "Errors occurred while loading packages")
-		return fmt.Errorf("errors occurred while loading packages")
+	kind, err := getGeneratorKind(opts.Kind)
+	if err != nil {
+		return opts, kind, err
 	}
-	// Find the package that contains your interface
-	var pkg *packages.Package
-	for _, p := range pkgs {
-		for _, file := range p.Syntax {
-			ctxLogger.Info(ctx, "File", zap.String("name", p.Fset.Position(file.Package).Filename), zap.String("path", i.FilePath))
-			if strings.HasSuffix(p.Fset.Position(file.Package).Filename, i.FilePath) {
-				pkg = p
-				break
-			}
+	if kind.Plugin == "" {
+		if opts.Template == "" {
+			opts.Template = kind.Template
 		}
-		if pkg != nil {
-			break
+		if _, err := parseTemplate(opts.Kind, opts.Template, opts.Partials, nil); err != nil {
+			return opts, kind, err
 		}
 	}
-
-	if pkg == nil {
-		ctxLogger.Info(ctx, "pkgs", zap.Any("pkgs", pkgs))
-		return fmt.Errorf("could not find package containing %s", i.FilePath)
-	}
-
-	for _, name := range i.Interfaces {
-		interfaceSource := getInterfaceSourceFromPackage(pkg, name)
-		if interfaceSource == nil {
-			ctxLogger.Error(ctx, "Failed to parse interface source", zap.String("interface", name))
-			continue
-		}
-
-		methods := getMethods(ctx, "/"+name, interfaceSource, pkg.TypesInfo)
-		ctxLogger.Info(ctx, "Found methods", zap.Int("count", len(methods)))
-		ctxLogger.Info(ctx, "Methods", zap.Any("methods", methods))
-		// Proceed to generate handlers using the methods
-		// ...
+	if opts.PackageName == "" {
+		opts.PackageName = filepath.Base(opts.OutputDir)
 	}
-	// Proceed as before...
-	return nil
+	return opts, kind, nil
 }
 
-func getModuleRootDir() (string, error) {
-	cmd := exec.Command("go", "list", "-m", "-f", "{{.Dir}}")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(string(output)), nil
+func routePrefix(prefix, name string) string {
+	return path.Join("/", prefix, name)
 }
 
-func getImportPath(filePath string) (string, error) {
-	modulePath, moduleDir, err := getModuleInfo()
-	if err != nil {
-		return "", err
+// renderInterface renders a single interface of pkg. Types are rewritten to
+// use import aliases, and the output is formatted with unused imports removed.
+func renderInterface(ctx context.Context, names map[string]string, i Interface, kind generatorKind, opts RenderOptions, diags *diagnostics) (GeneratedFile, error) {
+	name := i.Name
+	im := newImporter(names)
+	im.reserve(i.ImportPath, i.PackageName)
+
+	replace := TemplateReplace{
+		PackageName:        i.PackageName,
+		InterfaceName:      name,
+		ImportName:         i.ImportPath,
+		DirPackageName:     opts.PackageName,
+		NeedsContextImport: false,
+		NeedsStrconvImport: false,
+		NeedsJSONImport:    true,
+		NeedsNetHTTPImport: true,
+		NeedsFmtImport:     false,
+		InterfaceDoc:       i.Doc,
+		Types:              i.Types,
+		Options:            opts.Options,
+	}
+	if importPath, _, err := newModuleResolver().importPath(opts.OutputDir); err == nil {
+		replace.OutputImportPath = importPath
+	}
+	for _, m := range i.Methods {
+		method := m
+		method.Params = slices.Clone(m.Params)
+		method.Returns = slices.Clone(m.Returns)
+		for j := range method.Params {
+			method.Params[j].Type = im.qualify(method.Params[j].Type)
+			if method.Params[j].Type == "int" {
+				replace.NeedsStrconvImport = true
+			}
+			if method.Params[j].Binding == "path" {
+				replace.NeedsMuxImport = true
+			}
+		}
+		for j := range method.Returns {
+			method.Returns[j].Type = im.qualify(method.Returns[j].Type)
+		}
+		method.RequestType = im.qualify(method.RequestType)
+		method.ResponseType = im.qualify(method.ResponseType)
+		if method.HasContext {
+			replace.NeedsContextImport = true
+		}
+		replace.Methods = append(replace.Methods, method)
 	}
 
-	absFilePath, err := filepath.Abs(filePath)
-	if err != nil {
-		return "", err
+	// Templates may import more packages through qualify and importAlias while
+	// rendering, in which case the imports are only complete on a second pass.
+	var buf bytes.Buffer
+	for pass := 0; pass < 2; pass++ {
+		used := len(im.used)
+		replace.Imports, replace.ImportAliases = im.imports()
+		tmpl, err := parseTemplate(opts.Kind, opts.Template, opts.Partials, im)
+		if err != nil {
+			return GeneratedFile{}, err
+		}
+		buf.Reset()
+		if err := tmpl.Execute(&buf, replace); err != nil {
+			return GeneratedFile{}, fmt.Errorf("failed to render %s for %s: %w", opts.Kind, name, err)
+		}
+		if len(im.used) == used {
+			break
+		}
 	}
 
-	relPath, err := filepath.Rel(moduleDir, absFilePath)
+	filePath := filepath.Join(opts.OutputDir, fmt.Sprintf(kind.FileName, toSnakeCase(name)))
+	content, err := formatGenerated(filePath, buf.Bytes())
 	if err != nil {
-		return "", err
+		ctxLogger.Warn(ctx, "Generated code could not be formatted", zap.String("interface", name), zap.Error(err))
+		diags.addf(SeverityWarning, name, "generated code could not be formatted: %v", err)
 	}
-
-	importPath := path.Join(modulePath, filepath.ToSlash(relPath))
-	importPath = strings.TrimSuffix(importPath, ".go") // Remove .go if necessary
-
-	return importPath, nil
+	return GeneratedFile{Path: filePath, Content: content, Interface: name}, nil
 }
 
-func getModuleInfo() (modulePath string, moduleDir string, err error) {
-	cmd := exec.Command("go", "list", "-m", "-f", "{{.Path}}|{{.Dir}}")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", "", err
+// formatGenerated formats Go sources and removes their unused imports. Other
+// files and sources that do not parse are returned as they are.
+func formatGenerated(filePath string, src []byte) ([]byte, error) {
+	if filepath.Ext(filePath) != ".go" {
+		return src, nil
 	}
-	parts := strings.Split(strings.TrimSpace(string(output)), "|")
-	if len(parts) != 2 {
-		return "", "", fmt.Errorf("unexpected output from go list")
+	content, err := imports.Process(filePath, src, &imports.Options{Comments: true, TabIndent: true, TabWidth: 8})
+	if err != nil {
+		return src, err
 	}
-	return parts[0], parts[1], nil
+	return content, nil
 }
 
-func getInterfaceSourceFromPackage(pkg *packages.Package, interfaceName string) *ast.InterfaceType {
+// findInterface returns the declaration of the interface and its doc comment.
+func findInterface(pkg *packages.Package, interfaceName string) (*ast.InterfaceType, string) {
 	for _, file := range pkg.Syntax {
 		for _, decl := range file.Decls {
 			genDecl, ok := decl.(*ast.GenDecl)
@@ -163,15 +212,47 @@ func getInterfaceSourceFromPackage(pkg *packages.Package, interfaceName string)
 					continue
 				}
 				if interfaceType, ok := typeSpec.Type.(*ast.InterfaceType); ok {
-					return interfaceType
+					doc := typeSpec.Doc
+					if doc == nil && !genDecl.Lparen.IsValid() {
+						doc = genDecl.Doc
+					}
+					return interfaceType, strings.TrimSpace(doc.Text())
+				}
+			}
+		}
+	}
+	return nil, ""
+}
+
+// interfaceTypes returns the named types used by the methods of interfaceSource.
+func interfaceTypes(interfaceSource *ast.InterfaceType, info *types.Info) []TypeDef {
+	c := newTypeCollector()
+	for _, m := range interfaceSource.Methods.List {
+		funcType, ok := m.Type.(*ast.FuncType)
+		if !ok {
+			continue
+		}
+		for _, list := range []*ast.FieldList{funcType.Params, funcType.Results} {
+			if list == nil {
+				continue
+			}
+			for _, field := range list.List {
+				expr := field.Type
+				if ellipsis, ok := expr.(*ast.Ellipsis); ok {
+					expr = ellipsis.Elt
+				}
+				if t := info.TypeOf(expr); t != nil && exprToString(expr, info) != "context.Context" {
+					c.add(t)
 				}
 			}
 		}
 	}
-	return nil
+	return c.list()
 }
 
-func getMethods(ctx context.Context, name string, interfaceSource *ast.InterfaceType, info *types.Info) []*Method {
+// getMethods builds the methods of an interface with routes under name. With pathParams, the
+// params a route names are bound to its path, otherwise every param is a query param.
+func getMethods(ctx context.Context, name string, pathParams bool, interfaceSource *ast.InterfaceType, info *types.Info) []*Method {
 	var methods []*Method
 
 	for _, m := range interfaceSource.Methods.List {
@@ -190,6 +271,8 @@ func getMethods(ctx context.Context, name string, interfaceSource *ast.Interface
 		// Initialize the Method struct
 		method := Method{
 			Name:         methodName,
+			Doc:          strings.TrimSpace(m.Doc.Text()),
+			Directives:   methodDirectives(m.Doc, m.Comment),
 			HTTPMethod:   "",
 			HandlerName:  "",
 			URLPath:      "",
@@ -204,10 +287,14 @@ func getMethods(ctx context.Context, name string, interfaceSource *ast.Interface
 		// Extract parameters
 		for _, field := range funcType.Params.List {
 			paramType := exprToString(field.Type, info)
+			pkgPath, isPointer, isVariadic := typeDetails(field.Type, info)
 			for _, name := range field.Names {
 				param := Param{
-					Name: name.Name,
-					Type: paramType,
+					Name:      name.Name,
+					Type:      paramType,
+					Package:   pkgPath,
+					IsPointer: isPointer,
+					IsElipse:  isVariadic,
 				}
 
 				if paramType == "context.Context" {
@@ -227,10 +314,17 @@ func getMethods(ctx context.Context, name string, interfaceSource *ast.Interface
 		if funcType.Results != nil {
 			for _, field := range funcType.Results.List {
 				returnType := exprToString(field.Type, info)
+				pkgPath, isPointer, isVariadic := typeDetails(field.Type, info)
 				ret := Return{
-					Type: returnType,
+					Type:      returnType,
+					Package:   pkgPath,
+					IsPointer: isPointer,
+					IsElipse:  isVariadic,
+				}
+				// Named results declare one value per name
+				for range max(len(field.Names), 1) {
+					method.Returns = append(method.Returns, ret)
 				}
-				method.Returns = append(method.Returns, ret)
 			}
 		}
 
@@ -244,13 +338,99 @@ func getMethods(ctx context.Context, name string, interfaceSource *ast.Interface
 
 		// Infer HTTPMethod and URLPath based on method name or custom tags
 		method.HTTPMethod = inferHTTPMethod(methodName)
-		method.URLPath = inferURLPath(name, methodName)
+		method.HandlerName = methodName
+		var routeParams []Param
+		if pathParams {
+			routeParams = method.Params
+		}
+		method.URLPath = inferURLPath(name, methodName, routeParams...)
+		for j, p := range method.Params {
+			if strings.Contains(method.URLPath, "{"+strings.ToLower(p.Name)+"}") {
+				method.Params[j].Binding = "path"
+				continue
+			}
+			method.Params[j].Binding = "query"
+			method.QueryParams = append(method.QueryParams, p.Name)
+		}
 
 		methods = append(methods, &method)
 	}
 	return methods
 }
 
+// typeDetails returns the import path of the named type expr refers to, and
+// whether expr is a pointer or a variadic parameter.
+func typeDetails(expr ast.Expr, info *types.Info) (string, bool, bool) {
+	ellipsis, isVariadic := expr.(*ast.Ellipsis)
+	if isVariadic {
+		expr = ellipsis.Elt
+	}
+	t := info.TypeOf(expr)
+	if t == nil {
+		return "", false, isVariadic
+	}
+	_, isPointer := t.(*types.Pointer)
+	for {
+		switch u := t.(type) {
+		case *types.Pointer:
+			t = u.Elem()
+			continue
+		case *types.Slice:
+			t = u.Elem()
+			continue
+		case *types.Array:
+			t = u.Elem()
+			continue
+		case *types.Named:
+			if u.Obj().Pkg() != nil {
+				return u.Obj().Pkg().Path(), isPointer, isVariadic
+			}
+		}
+		return "", isPointer, isVariadic
+	}
+}
+
+// methodDirectives returns the //interfacery: directives of the comments, without the prefix.
+func methodDirectives(docs ...*ast.CommentGroup) []string {
+	var directives []string
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		for _, c := range doc.List {
+			if directive, ok := strings.CutPrefix(c.Text, DirectivePrefix); ok && directive != "" {
+				directives = append(directives, strings.TrimSpace(directive))
+			}
+		}
+	}
+	return directives
+}
+
+// idempotentVerbs start the names of the methods that are idempotent unless a directive says
+// otherwise: the reads and the writes of CRUD-style interfaces that replace or remove a value.
+var idempotentVerbs = []string{"Get", "List", "Put", "Update", "Delete", "Remove"}
+
+// isIdempotent reports whether calling the method twice has the effect of calling it once,
+// so that it can be retried. The idempotent and nonidempotent directives decide, otherwise
+// methods whose names start with one of idempotentVerbs are. Other names, such as SendEmail
+// or CreateUser, are not.
+func isIdempotent(m Method) bool {
+	for _, directive := range m.Directives {
+		switch directive {
+		case "idempotent":
+			return true
+		case "nonidempotent":
+			return false
+		}
+	}
+	for _, verb := range idempotentVerbs {
+		if strings.HasPrefix(m.Name, verb) {
+			return true
+		}
+	}
+	return false
+}
+
 // Infer HTTP method from the method name (simple heuristic)
 func inferHTTPMethod(methodName string) string {
 	if strings.HasPrefix(methodName, "Get") {
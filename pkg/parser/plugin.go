@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Seann-Moser/go-serve/pkg/ctxLogger"
+	"go.uber.org/zap"
+)
+
+// PluginPrefix is the executable name prefix of external generators. A generator kind
+// that is not built in runs the PluginPrefix+kind executable found on the PATH.
+const PluginPrefix = "interfacery-gen-"
+
+// PluginVersion is the version of the plugin protocol.
+const PluginVersion = 1
+
+// PluginRequest is written as JSON to the stdin of a plugin. It holds every
+// interface of one target.
+type PluginRequest struct {
+	Version     int               `json:"version"`
+	Kind        string            `json:"kind"`
+	OutputDir   string            `json:"outputDir"`
+	PackageName string            `json:"packageName"` // Package name of the generated Go files
+	RoutePrefix string            `json:"routePrefix,omitempty"`
+	Options     map[string]string `json:"options,omitempty"` // Target options from the project config
+	Interfaces  []Interface       `json:"interfaces"`
+}
+
+// PluginResponse is read as JSON from the stdout of a plugin.
+type PluginResponse struct {
+	Files       []PluginFile `json:"files"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+	Error       string       `json:"error,omitempty"` // Fails the run when set
+}
+
+// PluginFile is a file generated by a plugin. Go files are formatted like the built-in outputs.
+type PluginFile struct {
+	Path      string `json:"path"` // Relative to the OutputDir of the request
+	Content   string `json:"content"`
+	Interface string `json:"interface,omitempty"`
+}
+
+// RunPlugin implements the plugin side of the protocol: it reads the request from stdin,
+// calls generate and writes the response to stdout. Errors are reported in the response.
+func RunPlugin(generate func(*PluginRequest) (*PluginResponse, error)) {
+	if err := runPlugin(os.Stdin, os.Stdout, generate); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runPlugin(r io.Reader, w io.Writer, generate func(*PluginRequest) (*PluginResponse, error)) error {
+	var req PluginRequest
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return fmt.Errorf("failed to read plugin request: %w", err)
+	}
+	resp, err := generate(&req)
+	if err != nil {
+		resp = &PluginResponse{Error: err.Error()}
+	}
+	return json.NewEncoder(w).Encode(resp)
+}
+
+// lookupPlugin returns the generator kind of the plugin executable for kind.
+func lookupPlugin(kind string) (generatorKind, error) {
+	if kind == "" || strings.ContainsAny(kind, `/\`) {
+		return generatorKind{}, fmt.Errorf("invalid plugin name %q", kind)
+	}
+	executable, err := exec.LookPath(PluginPrefix + kind)
+	if err != nil {
+		return generatorKind{}, err
+	}
+	return generatorKind{Plugin: executable}, nil
+}
+
+// runPluginGenerator sends the interfaces to the plugin of kind and returns the files it generated.
+func runPluginGenerator(ctx context.Context, kind generatorKind, opts RenderOptions, interfaces []Interface, diags *diagnostics) ([]GeneratedFile, error) {
+	req := PluginRequest{
+		Version:     PluginVersion,
+		Kind:        opts.Kind,
+		OutputDir:   opts.OutputDir,
+		PackageName: opts.PackageName,
+		RoutePrefix: opts.RoutePrefix,
+		Options:     opts.Options,
+		Interfaces:  interfaces,
+	}
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, kind.Plugin)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	ctxLogger.Info(ctx, "Running plugin", zap.String("plugin", kind.Plugin), zap.Int("interfaces", len(interfaces)))
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("plugin %s failed: %w: %s", opts.Kind, err, strings.TrimSpace(stderr.String()))
+	}
+	if stderr.Len() > 0 {
+		ctxLogger.Warn(ctx, "Plugin wrote to stderr", zap.String("plugin", opts.Kind), zap.String("stderr", stderr.String()))
+	}
+
+	var resp PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("plugin %s returned an invalid response: %w", opts.Kind, err)
+	}
+	for _, d := range resp.Diagnostics {
+		diags.add(d)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", opts.Kind, resp.Error)
+	}
+
+	files := make([]GeneratedFile, 0, len(resp.Files))
+	for _, f := range resp.Files {
+		rel := filepath.Clean(filepath.FromSlash(f.Path))
+		if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("plugin %s: %s is outside of the output directory", opts.Kind, f.Path)
+		}
+		filePath := filepath.Join(opts.OutputDir, rel)
+		content, err := formatGenerated(filePath, []byte(f.Content))
+		if err != nil {
+			ctxLogger.Warn(ctx, "Generated code could not be formatted", zap.String("path", filePath), zap.Error(err))
+			diags.addf(SeverityWarning, f.Interface, "%s could not be formatted: %v", f.Path, err)
+		}
+		files = append(files, GeneratedFile{Path: filePath, Content: content, Interface: f.Interface})
+	}
+	return files, nil
+}
@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestMain lets the test binary act as a plugin when it is run as one.
+func TestMain(m *testing.M) {
+	if os.Getenv("INTERFACERY_TEST_PLUGIN") == "1" {
+		RunPlugin(testPlugin)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func testPlugin(req *PluginRequest) (*PluginResponse, error) {
+	if req.Options["fail"] != "" {
+		return nil, errors.New(req.Options["fail"])
+	}
+	var names []string
+	for _, i := range req.Interfaces {
+		for _, m := range i.Methods {
+			names = append(names, i.Name+"."+m.Name+" "+m.HTTPMethod+" "+m.URLPath)
+		}
+	}
+	path := "names.txt"
+	if req.Options["path"] != "" {
+		path = req.Options["path"]
+	}
+	return &PluginResponse{
+		Files: []PluginFile{
+			{Path: path, Content: strings.Join(names, "\n") + "\n"},
+			{Path: "sub/names.go", Content: "package " + req.PackageName + "\nconst   Count = " + strconv.Itoa(len(names)) + "\n"},
+		},
+		Diagnostics: []Diagnostic{{Severity: SeverityWarning, Message: "from plugin"}},
+	}, nil
+}
+
+func TestPluginGenerator(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are linked into a temporary PATH directory")
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := t.TempDir()
+	if err := os.Symlink(executable, filepath.Join(bin, PluginPrefix+"names")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("INTERFACERY_TEST_PLUGIN", "1")
+
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\ntype UserService interface {\n\tGetUser(id string) (string, error)\n\tDeleteUser(id string) error\n}\n")
+	writeTestFile(t, filepath.Join(root, "orders", "orders.go"), "package orders\n\ntype OrderService interface {\n\tListOrders() ([]string, error)\n}\n")
+	out := filepath.Join(root, "out")
+
+	target := Target{Name: "names", Kind: "names", Sources: []string{root}, Output: out, RoutePrefix: "/api"}
+	fsys := NewMemFS(nil)
+	result, err := NewGenerator(WithTargets(target), WithOutputFS(fsys)).Generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 2 {
+		t.Fatalf("got %+v, want two files from one plugin run", result.Files)
+	}
+	names, _ := fsys.ReadFile(filepath.Join(out, "names.txt"))
+	expected := "OrderService.ListOrders GET /api/orderservice/orders\nUserService.GetUser GET /api/userservice/user\nUserService.DeleteUser DELETE /api/userservice/user\n"
+	if string(names) != expected {
+		t.Errorf("got %q, want %q", names, expected)
+	}
+	code, _ := fsys.ReadFile(filepath.Join(out, "sub", "names.go"))
+	if string(code) != "package out\n\nconst Count = 3\n" {
+		t.Errorf("got %q, want the plugin output to be formatted", code)
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Message != "from plugin" {
+		t.Errorf("got %+v, want the plugin diagnostics", result.Diagnostics)
+	}
+
+	for name, options := range map[string]map[string]string{
+		"Error":           {"fail": "boom"},
+		"OutsideOfOutput": {"path": "../escape.txt"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			target := target
+			target.Options = options
+			if _, err := NewGenerator(WithTargets(target), WithOutputFS(NewMemFS(nil))).Generate(context.Background()); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	target.Kind = "missing"
+	if _, err := NewGenerator(WithTargets(target)).Generate(context.Background()); err == nil {
+		t.Error("expected an error for a missing plugin")
+	}
+}
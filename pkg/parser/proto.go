@@ -0,0 +1,538 @@
+package parser
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ProtoService is the protobuf model of an interface, as returned by the protoService template function.
+// Go types and expressions in the model use the import aliases of the file being rendered.
+type ProtoService struct {
+	Name      string // Service name, the interface name
+	Doc       string
+	Package   string          // Proto package
+	GoPackage string          // Import path of the code generated by protoc-gen-go, the go_package option
+	GoAlias   string          // Identifier of GoPackage in the rendered file
+	Helper    string          // Prefix of the conversion helpers generated next to the adapters
+	Helpers   map[string]bool // Helpers used by the conversions, by name without the prefix
+	Imports   []string        // Well-known .proto files used by the messages
+	Methods   []ProtoMethod
+	Messages  []ProtoMessage // Messages of the struct types used by the methods
+}
+
+// ProtoMethod is an rpc of a ProtoService. Its request holds the params of the Go method and
+// its response the returns that are not errors.
+type ProtoMethod struct {
+	Name     string
+	Doc      string
+	Request  ProtoMessage
+	Response ProtoMessage
+	Method   Method
+}
+
+// ProtoMessage is a protobuf message.
+type ProtoMessage struct {
+	Name   string
+	GoType string // Go struct converted to and from the message, empty for requests and responses
+	Fields []ProtoField
+}
+
+// ProtoField is a field of a ProtoMessage.
+type ProtoField struct {
+	Name   string // snake_case field name
+	Number int
+	Type   string // Proto type, e.g. "repeated User" or "map<string, int64>"
+	GoName string // Name of the field in the code generated by protoc-gen-go
+	GoType string // Go type of the param, return or struct field
+	Source string // Name of the param, return variable or struct field
+	JSON   bool   // Encoded as JSON bytes, for Go types without a protobuf equivalent
+	// Fallible is set when FromProto decodes JSON or converts messages, the expression records
+	// the first decode error in a variable errp of type *error that must be in scope.
+	Fallible bool
+
+	toProto, fromProto func(value string) string
+}
+
+// ToProto returns a Go expression converting value of GoType to the type protoc-gen-go generates for the field.
+func (f ProtoField) ToProto(value string) string {
+	return f.toProto(value)
+}
+
+// FromProto returns a Go expression converting value of the generated field type to GoType,
+// see Fallible for the errors of the conversion.
+func (f ProtoField) FromProto(value string) string {
+	return f.fromProto(value)
+}
+
+// Fallible reports whether converting any of the fields from protobuf can fail.
+func (m ProtoMessage) Fallible() bool {
+	for _, f := range m.Fields {
+		if f.Fallible {
+			return true
+		}
+	}
+	return false
+}
+
+// Field returns the field of the param or return variable named source.
+func (m ProtoMessage) Field(source string) (ProtoField, error) {
+	for _, f := range m.Fields {
+		if f.Source == source {
+			return f, nil
+		}
+	}
+	return ProtoField{}, fmt.Errorf("message %s has no field for %s", m.Name, source)
+}
+
+// protoType describes how a Go type is represented in protobuf.
+type protoType struct {
+	name      string // Proto type without label
+	goType    string // Go type generated by protoc-gen-go
+	repeated  bool
+	optional  bool
+	json      bool
+	fallible  bool // fromProto refers to errp
+	toProto   func(value string) string
+	fromProto func(value string) string
+}
+
+type protoScalar struct {
+	name   string
+	goType string
+}
+
+var protoScalars = map[string]protoScalar{
+	"bool":    {"bool", "bool"},
+	"string":  {"string", "string"},
+	"int":     {"int64", "int64"},
+	"int64":   {"int64", "int64"},
+	"int8":    {"int32", "int32"},
+	"int16":   {"int32", "int32"},
+	"int32":   {"int32", "int32"},
+	"rune":    {"int32", "int32"},
+	"uint":    {"uint64", "uint64"},
+	"uint64":  {"uint64", "uint64"},
+	"uintptr": {"uint64", "uint64"},
+	"uint8":   {"uint32", "uint32"},
+	"byte":    {"uint32", "uint32"},
+	"uint16":  {"uint32", "uint32"},
+	"uint32":  {"uint32", "uint32"},
+	"float32": {"float", "float32"},
+	"float64": {"double", "float64"},
+}
+
+// protoWellKnown maps Go types to the well-known protobuf types representing them.
+var protoWellKnown = map[string]struct {
+	name, file, goPackage, goType, toProto, fromProto string
+}{
+	"time.Time":     {"google.protobuf.Timestamp", "google/protobuf/timestamp.proto", "google.golang.org/protobuf/types/known/timestamppb", "Timestamp", "New", "AsTime"},
+	"time.Duration": {"google.protobuf.Duration", "google/protobuf/duration.proto", "google.golang.org/protobuf/types/known/durationpb", "Duration", "New", "AsDuration"},
+}
+
+// protoBuilder builds the ProtoService of an interface.
+type protoBuilder struct {
+	im       *importer
+	svc      *ProtoService
+	defs     map[string]TypeDef // Named types by their qualified Go type
+	messages map[string]int     // Index in svc.Messages by Go type
+	names    map[string]string  // Go type by message name
+	imports  map[string]bool
+}
+
+// buildProtoService returns the protobuf model of the interface rendered with r.
+// The options proto_package and go_package set the prefix of the proto package and the
+// import path the generated protobuf packages are placed under.
+func buildProtoService(im *importer, r TemplateReplace) *ProtoService {
+	lower := strings.ToLower(r.InterfaceName)
+	protoPackage := orFunc(r.Options["proto_package"], r.PackageName)
+	goPackage := orFunc(r.Options["go_package"], r.OutputImportPath)
+	b := &protoBuilder{
+		im: im,
+		svc: &ProtoService{
+			Name:      r.InterfaceName,
+			Doc:       r.InterfaceDoc,
+			Package:   protoIdent(protoPackage) + "." + protoIdent(lower),
+			GoPackage: path.Join(goPackage, lower+"pb"),
+			Helper:    lowerFirst(r.InterfaceName),
+			Helpers:   map[string]bool{},
+		},
+		defs:     map[string]TypeDef{},
+		messages: map[string]int{},
+		names:    map[string]string{},
+		imports:  map[string]bool{},
+	}
+	b.svc.GoAlias = im.alias(b.svc.GoPackage)
+	for _, def := range r.Types {
+		b.defs[im.qualify(def.Name)] = def
+	}
+	// Requests and responses take precedence over the messages of Go types
+	for _, m := range r.Methods {
+		b.names[m.Name+"Request"] = ""
+		b.names[m.Name+"Response"] = ""
+	}
+
+	for _, m := range r.Methods {
+		method := ProtoMethod{
+			Name:     m.Name,
+			Doc:      m.Doc,
+			Request:  ProtoMessage{Name: m.Name + "Request"},
+			Response: ProtoMessage{Name: m.Name + "Response"},
+			Method:   m,
+		}
+		var requestFields []protoSource
+		for _, p := range m.Params {
+			requestFields = append(requestFields, protoSource{name: p.Name, source: p.Name, goType: p.Type})
+		}
+		method.Request.Fields = b.fields(requestFields)
+
+		var responseFields []protoSource
+		names := returnNames(m.Returns)
+		for j, ret := range m.Returns {
+			if ret.Type != "error" {
+				responseFields = append(responseFields, protoSource{name: names[j], source: names[j], goType: ret.Type})
+			}
+		}
+		method.Response.Fields = b.fields(responseFields)
+		b.svc.Methods = append(b.svc.Methods, method)
+	}
+
+	for file := range b.imports {
+		b.svc.Imports = append(b.svc.Imports, file)
+	}
+	sort.Strings(b.svc.Imports)
+	return b.svc
+}
+
+type protoSource struct {
+	name   string
+	source string
+	goType string
+}
+
+// fields numbers the fields of a message in declaration order.
+func (b *protoBuilder) fields(sources []protoSource) []ProtoField {
+	var fields []ProtoField
+	seen := map[string]bool{}
+	for _, s := range sources {
+		name := protoIdent(toSnakeCase(s.name))
+		for i := 2; seen[name]; i++ {
+			name = fmt.Sprintf("%s_%d", protoIdent(toSnakeCase(s.name)), i)
+		}
+		seen[name] = true
+
+		t := b.resolve(s.goType, true)
+		// Record the helpers the conversions use
+		t.toProto("v")
+		t.fromProto("v")
+		protoType := t.name
+		switch {
+		case t.repeated:
+			protoType = "repeated " + protoType
+		case t.optional:
+			protoType = "optional " + protoType
+		}
+		fields = append(fields, ProtoField{
+			Name:      name,
+			Number:    len(fields) + 1,
+			Type:      protoType,
+			GoName:    protoGoName(name),
+			GoType:    s.goType,
+			Source:    s.source,
+			JSON:      t.json,
+			Fallible:  t.fallible,
+			toProto:   t.toProto,
+			fromProto: t.fromProto,
+		})
+	}
+	return fields
+}
+
+// resolve maps the Go type t to protobuf. Labels such as repeated and optional are only
+// available to message fields, so nested lists, maps and optional values are encoded as JSON.
+func (b *protoBuilder) resolve(t string, field bool) protoType {
+	if isVariadicType(t) {
+		t = "[]" + elemType(t)
+	}
+	if s, ok := protoScalars[t]; ok {
+		return protoType{name: s.name, goType: s.goType, toProto: convertTo(s.goType, t), fromProto: convertTo(t, s.goType)}
+	}
+	if t == "[]byte" || t == "[]uint8" {
+		return protoType{name: "bytes", goType: "[]byte", toProto: identity, fromProto: identity}
+	}
+	if wk, ok := protoWellKnown[t]; ok {
+		b.imports[wk.file] = true
+		alias := b.im.alias(wk.goPackage)
+		return protoType{
+			name:      wk.name,
+			goType:    "*" + alias + "." + wk.goType,
+			toProto:   func(v string) string { return alias + "." + wk.toProto + "(" + v + ")" },
+			fromProto: func(v string) string { return v + "." + wk.fromProto + "()" },
+		}
+	}
+
+	switch {
+	case isPointerType(t):
+		return b.resolvePointer(t, field)
+	case isSliceType(t):
+		elem := b.resolve(elemType(t), false)
+		if !field || elem.json || elem.repeated {
+			break
+		}
+		return protoType{
+			name:      elem.name,
+			goType:    "[]" + elem.goType,
+			repeated:  true,
+			fallible:  elem.fallible,
+			toProto:   b.convertFunc("ConvertSlice", t, elem.goType, elem.toProto),
+			fromProto: b.convertFunc("ConvertSlice", "[]"+elem.goType, elemType(t), elem.fromProto),
+		}
+	case isMapType(t):
+		return b.resolveMap(t, field)
+	}
+
+	if def, ok := b.defs[t]; ok {
+		switch def.Kind {
+		case "struct":
+			if name := b.message(t, def); name != "" {
+				return b.messageType(name, false)
+			}
+		case "interface":
+		default:
+			// Named types are converted through their underlying type
+			underlying := b.im.qualify(def.Underlying)
+			u := b.resolve(underlying, field)
+			if u.json {
+				break
+			}
+			if _, ok := protoScalars[underlying]; ok {
+				u.toProto, u.fromProto = convertTo(u.goType, t), convertTo(t, u.goType)
+			} else {
+				toUnderlying, fromUnderlying := u.toProto, u.fromProto
+				u.toProto = func(v string) string { return toUnderlying(underlying + "(" + v + ")") }
+				u.fromProto = func(v string) string { return t + "(" + fromUnderlying(v) + ")" }
+			}
+			return u
+		}
+	}
+	return b.json(t)
+}
+
+func (b *protoBuilder) resolvePointer(t string, field bool) protoType {
+	elem := elemType(t)
+	if wk, ok := protoWellKnown[elem]; ok {
+		inner := b.resolve(elem, false)
+		alias := b.im.alias(wk.goPackage)
+		inner.toProto = func(v string) string {
+			return fmt.Sprintf("%s(%s, %s.%s)", b.helper("ToMessage"), v, alias, wk.toProto)
+		}
+		inner.fromProto = func(v string) string {
+			return fmt.Sprintf("%s(%s, (*%s.%s).%s)", b.helper("FromMessage"), v, alias, wk.goType, wk.fromProto)
+		}
+		return inner
+	}
+	if def, ok := b.defs[elem]; ok && def.Kind == "struct" {
+		if name := b.message(elem, def); name != "" {
+			return b.messageType(name, true)
+		}
+	}
+	inner := b.resolve(elem, false)
+	if field && !inner.json && !inner.repeated && !strings.HasPrefix(inner.goType, "*") && !strings.HasPrefix(inner.goType, "[]") {
+		return protoType{
+			name:      inner.name,
+			goType:    "*" + inner.goType,
+			optional:  true,
+			fallible:  inner.fallible,
+			toProto:   b.convertFunc("ConvertPtr", t, inner.goType, inner.toProto),
+			fromProto: b.convertFunc("ConvertPtr", "*"+inner.goType, elem, inner.fromProto),
+		}
+	}
+	return b.json(t)
+}
+
+func (b *protoBuilder) resolveMap(t string, field bool) protoType {
+	key := strings.TrimSuffix(strings.TrimPrefix(t, "map["), "]"+elemType(t))
+	k, ok := protoScalars[key]
+	if !ok || !field || strings.HasPrefix(key, "float") {
+		return b.json(t)
+	}
+	value := b.resolve(elemType(t), false)
+	if value.json || value.repeated {
+		return b.json(t)
+	}
+	toKey, fromKey := convertTo(k.goType, key), convertTo(key, k.goType)
+	return protoType{
+		name:     "map<" + k.name + ", " + value.name + ">",
+		goType:   "map[" + k.goType + "]" + value.goType,
+		fallible: value.fallible,
+		toProto: func(v string) string {
+			if toKey("k") == "k" && value.toProto("v") == "v" {
+				return v
+			}
+			return fmt.Sprintf("%s(%s, func(k %s) %s { return %s }, func(v %s) %s { return %s })",
+				b.helper("ConvertMap"), v, key, k.goType, toKey("k"), elemType(t), value.goType, value.toProto("v"))
+		},
+		fromProto: func(v string) string {
+			if fromKey("k") == "k" && value.fromProto("v") == "v" {
+				return v
+			}
+			return fmt.Sprintf("%s(%s, func(k %s) %s { return %s }, func(v %s) %s { return %s })",
+				b.helper("ConvertMap"), v, k.goType, key, fromKey("k"), value.goType, elemType(t), value.fromProto("v"))
+		},
+	}
+}
+
+// messageType converts a struct to the message name, or a pointer to the struct when pointer is set.
+// Messages are fallible as their fields may be encoded as JSON.
+func (b *protoBuilder) messageType(name string, pointer bool) protoType {
+	return protoType{
+		name:     name,
+		goType:   "*" + b.svc.GoAlias + "." + name,
+		fallible: true,
+		toProto: func(v string) string {
+			if !pointer {
+				v = "&" + v
+			}
+			return fmt.Sprintf("%sToProto%s(%s)", b.svc.Helper, name, v)
+		},
+		fromProto: func(v string) string {
+			v = fmt.Sprintf("%sFromProto%s(%s, errp)", b.svc.Helper, name, v)
+			if !pointer {
+				v = fmt.Sprintf("%s(%s)", b.helper("Deref"), v)
+			}
+			return v
+		},
+	}
+}
+
+// json encodes Go types without a protobuf equivalent as JSON bytes.
+func (b *protoBuilder) json(t string) protoType {
+	return protoType{
+		name:      "bytes",
+		goType:    "[]byte",
+		json:      true,
+		fallible:  true,
+		toProto:   func(v string) string { return fmt.Sprintf("%s(%s)", b.helper("MarshalJSON"), v) },
+		fromProto: func(v string) string { return fmt.Sprintf("%s[%s](%s, errp)", b.helper("UnmarshalJSON"), t, v) },
+	}
+}
+
+// convertFunc converts the elements of a slice or pointer of type from with the helper fn,
+// it returns the value as it is when the elements do not need to be converted.
+func (b *protoBuilder) convertFunc(fn, from, to string, convert func(string) string) func(string) string {
+	return func(v string) string {
+		if convert("v") == "v" {
+			return v
+		}
+		return fmt.Sprintf("%s(%s, func(v %s) %s { return %s })", b.helper(fn), v, elemType(from), to, convert("v"))
+	}
+}
+
+// message returns the name of the message of the struct type t, adding it on first use.
+// Types that can not be named in protobuf, such as instantiated generics, return an empty name.
+func (b *protoBuilder) message(t string, def TypeDef) string {
+	if i, ok := b.messages[t]; ok {
+		return b.svc.Messages[i].Name
+	}
+	name := typeName(t)
+	if strings.ContainsAny(name, "[]") {
+		return ""
+	}
+	if b.taken(name) {
+		prefix := toPascalCase(typePackage(t)) + typeName(t)
+		name = prefix
+		for i := 2; b.taken(name); i++ {
+			name = fmt.Sprintf("%s%d", prefix, i)
+		}
+	}
+	b.names[name] = t
+	// Register the message before its fields, so recursive types terminate
+	b.messages[t] = len(b.svc.Messages)
+	b.svc.Messages = append(b.svc.Messages, ProtoMessage{Name: name, GoType: t})
+
+	var sources []protoSource
+	for _, f := range def.Fields {
+		sources = append(sources, protoSource{name: f.JSONName, source: f.Name, goType: b.im.qualify(f.Type)})
+	}
+	fields := b.fields(sources)
+	b.svc.Messages[b.messages[t]].Fields = fields
+	return name
+}
+
+// helper returns the name of a conversion helper and records that it is used.
+func (b *protoBuilder) helper(name string) string {
+	b.svc.Helpers[name] = true
+	return b.svc.Helper + name
+}
+
+func (b *protoBuilder) taken(name string) bool {
+	_, ok := b.names[name]
+	return ok
+}
+
+func identity(v string) string {
+	return v
+}
+
+// convertTo returns a conversion to the Go type to, unless from is the same type.
+func convertTo(to, from string) func(string) string {
+	if to == from {
+		return identity
+	}
+	return func(v string) string { return to + "(" + v + ")" }
+}
+
+// protoIdent replaces the characters that are not valid in protobuf identifiers.
+func protoIdent(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '.' || r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, s)
+	if s == "" || !unicode.IsLetter(rune(s[0])) {
+		s = "x" + s
+	}
+	return s
+}
+
+// protoGoReserved are the names protoc-gen-go appends an underscore to, so fields do not collide with the generated methods.
+var protoGoReserved = map[string]bool{
+	"Reset": true, "String": true, "ProtoMessage": true, "Marshal": true, "Unmarshal": true,
+	"ExtensionRangeArray": true, "ExtensionMap": true, "Descriptor": true,
+}
+
+// protoGoName returns the name protoc-gen-go generates for a field, see GoCamelCase in google.golang.org/protobuf/internal/strs.
+func protoGoName(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.' && i+1 < len(s) && isLower(rune(s[i+1])):
+			// Skip over '.' in ".{{lowercase}}"
+		case c == '.':
+			b = append(b, '_')
+		case c == '_' && (i == 0 || s[i-1] == '.'):
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isLower(rune(s[i+1])):
+			// Skip over '_' in "_{{lowercase}}"
+		case '0' <= c && c <= '9':
+			b = append(b, c)
+		default:
+			if isLower(rune(c)) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isLower(rune(s[i+1])); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	name := string(b)
+	if protoGoReserved[name] {
+		name += "_"
+	}
+	return name
+}
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProtoService(t *testing.T) {
+	im := newImporter(nil)
+	im.reserve("example.com/app/users", "users")
+	svc := buildProtoService(im, TemplateReplace{
+		PackageName:      "users",
+		InterfaceName:    "UserService",
+		OutputImportPath: "example.com/app/api",
+		Options:          map[string]string{"proto_package": "app.v1"},
+		Methods: []Method{{
+			Name:    "Search",
+			Params:  []Param{{Name: "userID", Type: "[]int"}, {Name: "roles", Type: "...users.Role"}, {Name: "since", Type: "*time.Time"}},
+			Returns: []Return{{Type: "[]users.User"}, {Type: "map[string][]int"}, {Type: "error"}},
+		}},
+		Types: []TypeDef{
+			{Name: "example.com/app/users.Role", Kind: "basic", Underlying: "string"},
+			{Name: "example.com/app/users.User", Kind: "struct", Fields: []Field{
+				{Name: "ID", Type: "string", JSONName: "id"},
+				{Name: "Nick", Type: "*string", JSONName: "nick"},
+				{Name: "Friends", Type: "[]*example.com/app/users.User", JSONName: "friends"},
+				{Name: "Scores", Type: "map[string]float64", JSONName: "scores"},
+				{Name: "Created", Type: "time.Time", JSONName: "createdAt"},
+			}},
+		},
+	})
+
+	if svc.Package != "app.v1.userservice" || svc.GoPackage != "example.com/app/api/userservicepb" || svc.GoAlias != "userservicepb" {
+		t.Errorf("got package %s, go package %s as %s", svc.Package, svc.GoPackage, svc.GoAlias)
+	}
+	if strings.Join(svc.Imports, ",") != "google/protobuf/timestamp.proto" {
+		t.Errorf("got imports %v", svc.Imports)
+	}
+	method := svc.Methods[0]
+	if len(svc.Messages) != 1 {
+		t.Fatalf("got %d messages, want the User message", len(svc.Messages))
+	}
+
+	tests := []struct {
+		field     ProtoField
+		name      string
+		protoType string
+		goName    string
+		toProto   string
+		fromProto string
+	}{
+		{method.Request.Fields[0], "user_id", "repeated int64", "UserId", "userServiceConvertSlice(x, func(v int) int64 { return int64(v) })", "userServiceConvertSlice(x, func(v int64) int { return int(v) })"},
+		{method.Request.Fields[1], "roles", "repeated string", "Roles", "userServiceConvertSlice(x, func(v users.Role) string { return string(v) })", "userServiceConvertSlice(x, func(v string) users.Role { return users.Role(v) })"},
+		{method.Request.Fields[2], "since", "google.protobuf.Timestamp", "Since", "userServiceToMessage(x, timestamppb.New)", "userServiceFromMessage(x, (*timestamppb.Timestamp).AsTime)"},
+		{method.Response.Fields[0], "result0", "repeated User", "Result0", "userServiceConvertSlice(x, func(v users.User) *userservicepb.User { return userServiceToProtoUser(&v) })", "userServiceConvertSlice(x, func(v *userservicepb.User) users.User { return userServiceDeref(userServiceFromProtoUser(v, errp)) })"},
+		{method.Response.Fields[1], "result1", "bytes", "Result1", "userServiceMarshalJSON(x)", "userServiceUnmarshalJSON[map[string][]int](x, errp)"},
+		{svc.Messages[0].Fields[1], "nick", "optional string", "Nick", "x", "x"},
+		{svc.Messages[0].Fields[2], "friends", "repeated User", "Friends", "userServiceConvertSlice(x, func(v *users.User) *userservicepb.User { return userServiceToProtoUser(v) })", "userServiceConvertSlice(x, func(v *userservicepb.User) *users.User { return userServiceFromProtoUser(v, errp) })"},
+		{svc.Messages[0].Fields[3], "scores", "map<string, double>", "Scores", "x", "x"},
+		{svc.Messages[0].Fields[4], "created_at", "google.protobuf.Timestamp", "CreatedAt", "timestamppb.New(x)", "x.AsTime()"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.field
+			if f.Name != tt.name || f.Type != tt.protoType || f.GoName != tt.goName {
+				t.Errorf("got %s %s as %s, want %s %s as %s", f.Type, f.Name, f.GoName, tt.protoType, tt.name, tt.goName)
+			}
+			if got := f.ToProto("x"); got != tt.toProto {
+				t.Errorf("ToProto got %s, want %s", got, tt.toProto)
+			}
+			if got := f.FromProto("x"); got != tt.fromProto {
+				t.Errorf("FromProto got %s, want %s", got, tt.fromProto)
+			}
+		})
+	}
+	// Converting messages and JSON can fail, scalars and well-known types can not
+	if method.Request.Fallible() || !method.Response.Fallible() || !method.Response.Fields[1].Fallible {
+		t.Errorf("got fallible request %v and response %v", method.Request.Fallible(), method.Response.Fallible())
+	}
+	if !svc.Helpers["ConvertSlice"] || !svc.Helpers["MarshalJSON"] || svc.Helpers["ConvertMap"] {
+		t.Errorf("got helpers %v", svc.Helpers)
+	}
+}
@@ -0,0 +1,268 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PythonModule is the Python client model of an interface, as returned by the pythonModule template function.
+type PythonModule struct {
+	Classes []PythonClass // Dataclasses of the struct types, in order of first use
+	Aliases []PythonAlias // Named types that are not structs
+	Methods []PythonMethod
+}
+
+type PythonClass struct {
+	Name   string
+	GoType string
+	Fields []PythonField
+}
+
+type PythonField struct {
+	Name    string // Snake case attribute name
+	JSON    string // Key in the JSON encoding
+	Type    string
+	Default string // Default value expression, dataclasses.field(...) for mutable values
+}
+
+type PythonAlias struct {
+	Name string
+	Type string
+}
+
+type PythonMethod struct {
+	Name       string // Snake case method name
+	Doc        string
+	HTTPMethod string
+	URLPath    string
+	Params     []PythonParam
+	Returns    []string // Python types of the returns that are not errors
+	Method     Method
+}
+
+// ReturnType is the annotation of the method result, a Tuple for multiple returns.
+func (m PythonMethod) ReturnType() string {
+	switch len(m.Returns) {
+	case 0:
+		return "None"
+	case 1:
+		return m.Returns[0]
+	default:
+		return "Tuple[" + strings.Join(m.Returns, ", ") + "]"
+	}
+}
+
+type PythonParam struct {
+	Name     string // Python argument name
+	Query    string // Query parameter, the Go param name, unless the param is bound to the path
+	Type     string
+	Path     string // Placeholder in the URL path the value is substituted into instead of the query, such as {id}
+	Encoding string // How the query value is written, as in the Go client: str, int or json
+	Variadic bool
+}
+
+// pythonBuilder maps the Go types of an interface to Python annotations.
+type pythonBuilder struct {
+	im    *importer
+	mod   *PythonModule
+	defs  map[string]TypeDef // Named types by their qualified Go type
+	named map[string]string  // Python name by qualified Go type
+	taken map[string]bool
+}
+
+// buildPythonModule returns the Python client model of the interface rendered with r.
+func buildPythonModule(im *importer, r TemplateReplace) *PythonModule {
+	b := &pythonBuilder{
+		im:    im,
+		mod:   &PythonModule{},
+		defs:  map[string]TypeDef{},
+		named: map[string]string{},
+		taken: map[string]bool{},
+	}
+	for _, def := range r.Types {
+		b.defs[im.qualify(def.Name)] = def
+	}
+	for _, name := range []string{"APIError", "BadRequestError", "UnauthorizedError", "ForbiddenError", "NotFoundError", "ConflictError", "ServerError", "Any", "Dict", "List", "Optional", "Tuple", r.InterfaceName + "Client"} {
+		b.taken[name] = true
+	}
+
+	for _, m := range r.Methods {
+		method := PythonMethod{
+			Name:       pythonIdent(m.Name),
+			Doc:        m.Doc,
+			HTTPMethod: m.HTTPMethod,
+			URLPath:    m.URLPath,
+			Method:     m,
+		}
+		for _, p := range m.Params {
+			param := PythonParam{
+				Name:     pythonIdent(p.Name),
+				Query:    p.Name,
+				Type:     b.resolve(p.Type),
+				Encoding: "json",
+				Variadic: isVariadicType(p.Type),
+			}
+			switch p.Type {
+			case "string":
+				param.Encoding = "str"
+			case "int":
+				param.Encoding = "int"
+			}
+			if p.Binding == "path" {
+				param.Path = "{" + strings.ToLower(p.Name) + "}"
+			}
+			if param.Variadic {
+				param.Type = b.resolve(elemType(p.Type))
+			}
+			method.Params = append(method.Params, param)
+		}
+		for _, ret := range m.Returns {
+			if ret.Type != "error" {
+				method.Returns = append(method.Returns, b.resolve(ret.Type))
+			}
+		}
+		b.mod.Methods = append(b.mod.Methods, method)
+	}
+	return b.mod
+}
+
+// resolve returns the annotation of the Go type t.
+// Types without a fixed shape are Any.
+func (b *pythonBuilder) resolve(t string) string {
+	switch t {
+	case "bool":
+		return "bool"
+	case "string":
+		return "str"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte", "rune":
+		return "int"
+	case "float32", "float64":
+		return "float"
+	case "[]byte", "[]uint8":
+		return "bytes"
+	case "time.Time":
+		return "datetime.datetime"
+	case "time.Duration":
+		return "int"
+	}
+
+	switch {
+	case isVariadicType(t), isSliceType(t):
+		return "List[" + b.resolve(elemType(t)) + "]"
+	case isPointerType(t):
+		return "Optional[" + b.resolve(elemType(t)) + "]"
+	case isMapType(t):
+		key := strings.TrimSuffix(strings.TrimPrefix(t, "map["), "]"+elemType(t))
+		return "Dict[" + b.resolve(key) + ", " + b.resolve(elemType(t)) + "]"
+	}
+
+	def, ok := b.defs[t]
+	if !ok || def.Kind == "interface" {
+		return "Any"
+	}
+	if name, ok := b.named[t]; ok {
+		return name
+	}
+	name := b.name(t)
+	b.named[t] = name
+	if def.Kind != "struct" {
+		b.mod.Aliases = append(b.mod.Aliases, PythonAlias{Name: name, Type: b.resolve(b.im.qualify(def.Underlying))})
+		return name
+	}
+
+	// Register the class before its fields, so recursive types terminate
+	index := len(b.mod.Classes)
+	b.mod.Classes = append(b.mod.Classes, PythonClass{Name: name, GoType: t})
+	var fields []PythonField
+	for _, f := range def.Fields {
+		fieldType := b.resolve(b.im.qualify(f.Type))
+		fields = append(fields, PythonField{
+			Name:    pythonIdent(f.JSONName),
+			JSON:    f.JSONName,
+			Type:    fieldType,
+			Default: b.zero(fieldType),
+		})
+	}
+	b.mod.Classes[index].Fields = fields
+	return name
+}
+
+// name returns an unused Python name for the named Go type t.
+func (b *pythonBuilder) name(t string) string {
+	name := typeName(t)
+	if strings.ContainsAny(name, "[]") {
+		name = toPascalCase(strings.NewReplacer("[", " ", "]", " ", ",", " ", ".", " ", "*", " ").Replace(name))
+	}
+	if b.taken[name] {
+		prefix := toPascalCase(typePackage(t)) + name
+		name = prefix
+		for i := 2; b.taken[name]; i++ {
+			name = fmt.Sprintf("%s%d", prefix, i)
+		}
+	}
+	b.taken[name] = true
+	return name
+}
+
+// zero returns the default of a dataclass field, matching what Go decodes a missing key to.
+func (b *pythonBuilder) zero(pythonType string) string {
+	switch {
+	case pythonType == "str":
+		return `""`
+	case pythonType == "int":
+		return "0"
+	case pythonType == "float":
+		return "0.0"
+	case pythonType == "bool":
+		return "False"
+	case pythonType == "bytes":
+		return `b""`
+	case pythonType == "datetime.datetime":
+		return "field(default_factory=_zero_time)"
+	case strings.HasPrefix(pythonType, "List["):
+		return "field(default_factory=list)"
+	case strings.HasPrefix(pythonType, "Dict["):
+		return "field(default_factory=dict)"
+	case pythonType == "Any" || strings.HasPrefix(pythonType, "Optional["):
+		return "None"
+	}
+	for _, class := range b.mod.Classes {
+		if class.Name == pythonType {
+			return "field(default_factory=lambda: " + pythonType + "())"
+		}
+	}
+	for _, alias := range b.mod.Aliases {
+		if alias.Name == pythonType {
+			return b.zero(alias.Type)
+		}
+	}
+	return "None"
+}
+
+var pythonKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true,
+	"await": true, "break": true, "class": true, "continue": true, "def": true, "del": true, "elif": true,
+	"else": true, "except": true, "finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true, "or": true,
+	"pass": true, "raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+	"self": true,
+}
+
+// pythonIdent converts a Go or JSON name to a snake case Python identifier,
+// escaping keywords with a trailing underscore.
+func pythonIdent(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, toSnakeCase(name))
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "_" + name
+	}
+	if pythonKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPythonModule(t *testing.T) {
+	im := newImporter(nil)
+	im.reserve("example.com/app/users", "users")
+	mod := buildPythonModule(im, TemplateReplace{
+		PackageName:   "users",
+		InterfaceName: "UserService",
+		Methods: []Method{{
+			Name:       "GetUserByID",
+			HTTPMethod: "GET",
+			URLPath:    "/user/{id}",
+			Params:     []Param{{Name: "id", Type: "string", Binding: "path"}, {Name: "from", Type: "*time.Time", Binding: "query"}, {Name: "roles", Type: "...users.Role", Binding: "query"}},
+			Returns:    []Return{{Type: "*users.User"}, {Type: "int"}, {Type: "error"}},
+		}},
+		Types: []TypeDef{
+			{Name: "example.com/app/users.Role", Kind: "basic", Underlying: "string"},
+			{Name: "example.com/app/users.User", Kind: "struct", Fields: []Field{
+				{Name: "ID", Type: "string", JSONName: "id"},
+				{Name: "Friends", Type: "[]*example.com/app/users.User", JSONName: "friends"},
+				{Name: "Scores", Type: "map[int]float64", JSONName: "scores"},
+				{Name: "Created", Type: "time.Time", JSONName: "createdAt"},
+				{Name: "Raw", Type: "[]byte", JSONName: "raw-data"},
+				{Name: "Role", Type: "example.com/app/users.Role", JSONName: "role"},
+			}},
+		},
+	})
+
+	method := mod.Methods[0]
+	if method.Name != "get_user_by_id" || method.ReturnType() != "Tuple[Optional[User], int]" {
+		t.Errorf("got %s returning %s", method.Name, method.ReturnType())
+	}
+	expectedParams := []PythonParam{
+		{Name: "id", Query: "id", Type: "str", Path: "{id}", Encoding: "str"},
+		{Name: "from_", Query: "from", Type: "Optional[datetime.datetime]", Encoding: "json"},
+		{Name: "roles", Query: "roles", Type: "Role", Encoding: "json", Variadic: true},
+	}
+	if !reflect.DeepEqual(method.Params, expectedParams) {
+		t.Errorf("got params %+v, want %+v", method.Params, expectedParams)
+	}
+	if !reflect.DeepEqual(mod.Aliases, []PythonAlias{{Name: "Role", Type: "str"}}) {
+		t.Errorf("got aliases %+v", mod.Aliases)
+	}
+	expectedFields := []PythonField{
+		{Name: "id", JSON: "id", Type: "str", Default: `""`},
+		{Name: "friends", JSON: "friends", Type: "List[Optional[User]]", Default: "field(default_factory=list)"},
+		{Name: "scores", JSON: "scores", Type: "Dict[int, float]", Default: "field(default_factory=dict)"},
+		{Name: "created_at", JSON: "createdAt", Type: "datetime.datetime", Default: "field(default_factory=_zero_time)"},
+		{Name: "raw_data", JSON: "raw-data", Type: "bytes", Default: `b""`},
+		{Name: "role", JSON: "role", Type: "Role", Default: `""`},
+	}
+	if len(mod.Classes) != 1 || !reflect.DeepEqual(mod.Classes[0].Fields, expectedFields) {
+		t.Errorf("got classes %+v, want User with %+v", mod.Classes, expectedFields)
+	}
+}
+
+func TestGeneratorPython(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\ntype UserService interface {\n\tGetUserByID(id int, fields string) (string, error)\n}\n")
+	out := filepath.Join(root, "out")
+
+	fsys := NewMemFS(nil)
+	opts := []Option{WithSources(root), WithKind("python"), WithOutputDir(out), WithPathParams(true), WithOutputFS(fsys)}
+	if _, err := NewGenerator(opts...).Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := fsys.ReadFile(filepath.Join(out, "user_service_client.py"))
+	// The ID is only sent in the path
+	for _, want := range []string{
+		`path = path.replace("{id}", urllib.parse.quote(str(id), safe=""))`,
+		`query["fields"] = fields`,
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("got %s, want it to contain %s", content, want)
+		}
+	}
+	if strings.Contains(string(content), `query["id"]`) {
+		t.Errorf("got %s, want id left out of the query", content)
+	}
+}
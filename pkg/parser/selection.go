@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MarkerComment opts an interface in to generation when Selection.Marked is set.
+const MarkerComment = "//interfacery:generate"
+
+// DirectivePrefix starts the comments of an interface method configuring its generation,
+// such as //interfacery:idempotent.
+const DirectivePrefix = "//interfacery:"
+
+// Selection decides which interfaces are generated.
+//
+// A pattern is an interface name, a glob such as *Service or a regular expression
+// between slashes such as /^User.*Service$/. It can be qualified by a package
+// directory (./pkg/users.UserService), an import path (example.com/app/pkg/users.UserService)
+// or a package name (users.UserService). A qualifier ending in /... also matches
+// the packages below it. Relative directories are resolved against the working directory.
+type Selection struct {
+	Include    []string // Selected interfaces, every interface when empty
+	Exclude    []string // Interfaces removed from the selection
+	Marked     bool     // Only select interfaces whose declaration has a MarkerComment
+	Unexported bool     // Also select unexported interfaces
+	Tests      bool     // Also scan _test.go files
+	BuildTags  []string // Additional build tags satisfied during discovery and loading
+}
+
+// interfaceInfo is an interface found during discovery.
+type interfaceInfo struct {
+	Name       string
+	Marked     bool
+	Package    string
+	ImportPath string
+	Dir        string
+}
+
+type selector struct {
+	pkg     string // Import path, package name or absolute directory
+	dir     bool
+	subtree bool
+	match   func(string) bool
+}
+
+type selection struct {
+	Selection
+	include []selector
+	exclude []selector
+}
+
+func (s Selection) compile() (*selection, error) {
+	compiled := &selection{Selection: s}
+	for _, pattern := range s.Include {
+		sel, err := parseSelector(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled.include = append(compiled.include, sel)
+	}
+	for _, pattern := range s.Exclude {
+		sel, err := parseSelector(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled.exclude = append(compiled.exclude, sel)
+	}
+	return compiled, nil
+}
+
+// matches reports whether the interface is selected.
+func (s *selection) matches(i interfaceInfo) bool {
+	if !s.Unexported && !ast.IsExported(i.Name) {
+		return false
+	}
+	if s.Marked && !i.Marked {
+		return false
+	}
+	if len(s.include) > 0 && !matchesAny(s.include, i) {
+		return false
+	}
+	return !matchesAny(s.exclude, i)
+}
+
+func matchesAny(selectors []selector, i interfaceInfo) bool {
+	for _, sel := range selectors {
+		if sel.matches(i) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s selector) matches(i interfaceInfo) bool {
+	if !s.match(i.Name) {
+		return false
+	}
+	switch {
+	case s.pkg == "":
+		return true
+	case s.dir:
+		return matchesPath(s.pkg, i.Dir, s.subtree, string(filepath.Separator))
+	case s.subtree:
+		return matchesPath(s.pkg, i.ImportPath, true, "/")
+	default:
+		return s.pkg == i.ImportPath || s.pkg == i.Package
+	}
+}
+
+func matchesPath(pkg, p string, subtree bool, sep string) bool {
+	return p == pkg || (subtree && strings.HasPrefix(p, strings.TrimSuffix(pkg, sep)+sep))
+}
+
+// parseSelector parses a single selection pattern, see Selection.
+func parseSelector(pattern string) (selector, error) {
+	var sel selector
+	qualifier, name := splitSelector(pattern)
+	switch {
+	case name == "":
+		return sel, fmt.Errorf("invalid interface pattern %q: missing interface name", pattern)
+	case len(name) > 1 && strings.HasPrefix(name, "/") && strings.HasSuffix(name, "/"):
+		re, err := regexp.Compile(name[1 : len(name)-1])
+		if err != nil {
+			return sel, fmt.Errorf("invalid interface pattern %q: %w", pattern, err)
+		}
+		sel.match = re.MatchString
+	case strings.ContainsAny(name, "*?["):
+		if _, err := path.Match(name, ""); err != nil {
+			return sel, fmt.Errorf("invalid interface pattern %q: %w", pattern, err)
+		}
+		sel.match = func(s string) bool {
+			ok, _ := path.Match(name, s)
+			return ok
+		}
+	default:
+		sel.match = func(s string) bool { return s == name }
+	}
+
+	if strings.HasSuffix(qualifier, "/...") {
+		sel.subtree = true
+		qualifier = strings.TrimSuffix(qualifier, "/...")
+	}
+	if isDirQualifier(qualifier) {
+		dir, err := filepath.Abs(filepath.FromSlash(qualifier))
+		if err != nil {
+			return sel, err
+		}
+		sel.dir = true
+		qualifier = dir
+	}
+	sel.pkg = qualifier
+	return sel, nil
+}
+
+// splitSelector splits a pattern into its package qualifier and interface pattern.
+func splitSelector(pattern string) (string, string) {
+	if strings.HasSuffix(pattern, "/") {
+		// A regular expression, the qualifier is everything before its opening slash
+		if i := strings.LastIndex(pattern[:len(pattern)-1], "/"); i >= 0 {
+			if i == 0 {
+				return "", pattern
+			}
+			if pattern[i-1] == '.' {
+				return pattern[:i-1], pattern[i:]
+			}
+		}
+	}
+	lastSegment := strings.LastIndex(pattern, "/") + 1
+	i := strings.LastIndex(pattern[lastSegment:], ".")
+	if i < 0 {
+		return "", pattern
+	}
+	return pattern[:lastSegment+i], pattern[lastSegment+i+1:]
+}
+
+func isDirQualifier(qualifier string) bool {
+	return qualifier == "." || qualifier == ".." || strings.HasPrefix(qualifier, "./") ||
+		strings.HasPrefix(qualifier, "../") || filepath.IsAbs(qualifier)
+}
+
+// resolveSelector rewrites a relative package directory in pattern against baseDir.
+func resolveSelector(baseDir, pattern string) string {
+	qualifier, name := splitSelector(pattern)
+	subtree := strings.HasSuffix(qualifier, "/...")
+	dir := strings.TrimSuffix(qualifier, "/...")
+	if !isDirQualifier(dir) || filepath.IsAbs(dir) {
+		return pattern
+	}
+	dir = filepath.ToSlash(filepath.Join(baseDir, dir))
+	if subtree {
+		dir += "/..."
+	}
+	return dir + "." + name
+}
+
+// hasMarker reports whether any of the doc comments contains the MarkerComment.
+func hasMarker(docs ...*ast.CommentGroup) bool {
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		for _, c := range doc.List {
+			if c.Text == MarkerComment || strings.HasPrefix(c.Text, MarkerComment+" ") {
+				return true
+			}
+		}
+	}
+	return false
+}
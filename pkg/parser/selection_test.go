@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSelectionMatches(t *testing.T) {
+	dir, err := filepath.Abs(filepath.FromSlash("pkg/users"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := interfaceInfo{Name: "UserService", Package: "users", ImportPath: "example.com/app/pkg/users", Dir: dir}
+	marked := users
+	marked.Marked = true
+	unexported := users
+	unexported.Name = "userStore"
+
+	tests := []struct {
+		name      string
+		selection Selection
+		info      interfaceInfo
+		expected  bool
+	}{
+		{"Everything", Selection{}, users, true},
+		{"ExactName", Selection{Include: []string{"UserService"}}, users, true},
+		{"OtherName", Selection{Include: []string{"OrderService"}}, users, false},
+		{"Glob", Selection{Include: []string{"*Service"}}, users, true},
+		{"Regexp", Selection{Include: []string{"/^User/"}}, users, true},
+		{"RegexpNoMatch", Selection{Include: []string{"/^Order/"}}, users, false},
+		{"PackageDir", Selection{Include: []string{"./pkg/users.UserService"}}, users, true},
+		{"OtherPackageDir", Selection{Include: []string{"./pkg/orders.UserService"}}, users, false},
+		{"PackageDirSubtree", Selection{Include: []string{"./pkg/....*"}}, users, true},
+		{"ImportPath", Selection{Include: []string{"example.com/app/pkg/users.UserService"}}, users, true},
+		{"ImportPathSubtree", Selection{Include: []string{"example.com/app/....UserService"}}, users, true},
+		{"PackageName", Selection{Include: []string{"users.User*"}}, users, true},
+		{"QualifiedRegexp", Selection{Include: []string{"users./Service$/"}}, users, true},
+		{"Excluded", Selection{Exclude: []string{"*Service"}}, users, false},
+		{"Unmarked", Selection{Marked: true}, users, false},
+		{"Marked", Selection{Marked: true}, marked, true},
+		{"Unexported", Selection{}, unexported, false},
+		{"UnexportedAllowed", Selection{Unexported: true}, unexported, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, err := tt.selection.compile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sel.matches(tt.info); got != tt.expected {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelectionErrors(t *testing.T) {
+	for _, pattern := range []string{"users.", "/[/", "[Service"} {
+		if _, err := (Selection{Include: []string{pattern}}).compile(); err == nil {
+			t.Errorf("expected an error for %q", pattern)
+		}
+	}
+}
+
+func TestFindInterfacesSelection(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "users.go"), `package root
+
+// UserService manages users.
+//
+//interfacery:generate
+type UserService interface {
+	Get(id string) error
+}
+
+type OrderService interface {
+	List() error
+}
+
+type (
+	//interfacery:generate
+	Marked interface{ Run() }
+
+	Unmarked interface{ Run() }
+)
+
+type store interface {
+	Save() error
+}
+`)
+	writeTestFile(t, filepath.Join(root, "users_test.go"), "package root\n\ntype fakeService interface {\n\tGet(id string) error\n}\n\ntype TestHelper interface {\n\tHelp()\n}\n")
+
+	tests := []struct {
+		name      string
+		selection Selection
+		expected  []string
+	}{
+		{"Defaults", Selection{}, []string{"UserService", "OrderService", "Marked", "Unmarked"}},
+		{"Marked", Selection{Marked: true}, []string{"UserService", "Marked"}},
+		{"Tests", Selection{Tests: true, Include: []string{"*Helper"}}, []string{"TestHelper"}},
+		{"Unexported", Selection{Unexported: true, Exclude: []string{"/^[A-Z]/"}}, []string{"store"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := FindInterfaces(root, tt.selection)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, file := range result {
+				names = append(names, file.Interfaces...)
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("got %v, want %v", names, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+)
+
+// BuiltinTemplate is a template embedded in default_templates.
+type BuiltinTemplate struct {
+	Kind   string // Generator kind using the template
+	Name   string // File name, also used when the template is ejected
+	Source string
+}
+
+// BuiltinTemplates lists the embedded templates sorted by kind.
+func BuiltinTemplates() []BuiltinTemplate {
+	var templates []BuiltinTemplate
+	for kind, g := range generatorKinds {
+		templates = append(templates, BuiltinTemplate{Kind: kind, Name: g.TemplateFile, Source: g.Template})
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Kind < templates[j].Kind })
+	return templates
+}
+
+// EjectTemplates writes the built-in templates selected by name, a generator kind, a template
+// file name or "all", to dir so they can be customized and passed back with --template.
+// Existing files are only overwritten when force is set.
+func EjectTemplates(name, dir string, force bool) ([]string, error) {
+	var selected []BuiltinTemplate
+	for _, t := range BuiltinTemplates() {
+		if name == "all" || name == t.Kind || name == t.Name {
+			selected = append(selected, t)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("unknown template %q, expected all or one of %v", name, GeneratorKinds())
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, t := range selected {
+		filePath := filepath.Join(dir, t.Name)
+		if _, err := os.Stat(filePath); err == nil && !force {
+			return written, fmt.Errorf("%s already exists, use --force to overwrite it", filePath)
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return written, err
+		}
+		if err := os.WriteFile(filePath, []byte(t.Source), 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, filePath)
+	}
+	return written, nil
+}
+
+// ValidateTemplate renders a template for kind against SampleTemplateReplace and checks
+// that the output is valid Go, unless kind generates other files. It returns the rendered output.
+func ValidateTemplate(kind, source string, partials map[string]string) ([]byte, error) {
+	g, err := getGeneratorKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	sample := SampleTemplateReplace()
+	im := newImporter(nil)
+	im.reserve(sample.ImportName, sample.PackageName)
+	tmpl, err := parseTemplate(kind, source, partials, im)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, sample); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	if ext := filepath.Ext(g.FileName); ext != "" && ext != ".go" {
+		return buf.Bytes(), nil
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "generated.go", buf.Bytes(), parser.AllErrors); err != nil {
+		return buf.Bytes(), fmt.Errorf("rendered template is not valid Go: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SampleTemplateReplace is a representative interface used to validate templates.
+// It covers context params, basic and JSON encoded params, and every shape of returns.
+func SampleTemplateReplace() TemplateReplace {
+	return TemplateReplace{
+		PackageName:    "users",
+		InterfaceName:  "UserService",
+		ImportName:     "example.com/app/pkg/users",
+		DirPackageName: "client",
+		Methods: []Method{
+			{
+				Name: "GetUserByID", HandlerName: "GetUserByID", HTTPMethod: "GET", URLPath: "/userservice/user/{id}",
+				Params:     []Param{{Name: "id", Type: "string", Binding: "path"}},
+				Returns:    []Return{{Type: "*users.User"}, {Type: "error"}},
+				HasContext: true, RequestType: "string", ResponseType: "*users.User",
+			},
+			{
+				Name: "CreateUser", HandlerName: "CreateUser", HTTPMethod: "GET", URLPath: "/userservice/users",
+				Params: []Param{{Name: "user", Type: "users.User", Binding: "query"}}, QueryParams: []string{"user"},
+				Returns:    []Return{{Type: "*users.User"}, {Type: "error"}},
+				HasContext: true, RequestType: "users.User", ResponseType: "*users.User",
+			},
+			{
+				Name: "DeleteUser", HandlerName: "DeleteUser", HTTPMethod: "DELETE", URLPath: "/userservice/user/{id}",
+				Params:     []Param{{Name: "id", Type: "string", Binding: "path"}},
+				Returns:    []Return{{Type: "error"}},
+				HasContext: true, RequestType: "string", ResponseType: "error",
+			},
+			{
+				Name: "Count", HandlerName: "Count", HTTPMethod: "GET", URLPath: "/userservice/count",
+				Params:      []Param{{Name: "limit", Type: "int", Binding: "query"}, {Name: "filter", Type: "map[string]string", Binding: "query"}},
+				QueryParams: []string{"limit", "filter"},
+				Returns:     []Return{{Type: "int"}, {Type: "string"}, {Type: "error"}},
+				RequestType: "map[string]string", ResponseType: "int",
+			},
+			{
+				Name: "Tags", HandlerName: "Tags", HTTPMethod: "GET", URLPath: "/userservice/tags",
+				Params: []Param{{Name: "names", Type: "...string", Binding: "query"}}, QueryParams: []string{"names"},
+				Returns:     []Return{{Type: "[]string"}},
+				RequestType: "...string", ResponseType: "[]string",
+			},
+			{Name: "Ping", HandlerName: "Ping", HTTPMethod: "GET", URLPath: "/userservice/ping"},
+		},
+		Imports:            map[string]bool{},
+		ImportAliases:      map[string]string{},
+		NeedsContextImport: true,
+		NeedsStrconvImport: true,
+		NeedsJSONImport:    true,
+		NeedsNetHTTPImport: true,
+		NeedsMuxImport:     true,
+		InterfaceDoc:       "UserService manages users.",
+		Types: []TypeDef{{
+			Name:    "example.com/app/pkg/users.User",
+			Package: "example.com/app/pkg/users",
+			Kind:    "struct",
+			Fields: []Field{
+				{Name: "ID", Type: "string", JSONName: "id"},
+				{Name: "CreatedAt", Type: "time.Time", JSONName: "createdAt"},
+				{Name: "Labels", Type: "map[string]string", JSONName: "labels"},
+			},
+		}},
+		OutputImportPath: "example.com/app/pkg/client",
+	}
+}
+
+// LoadTemplateOverride reads a template override for a generator kind. templatePath is either
+// a template file, or a directory holding the kind's template under its built-in file name
+// (for example handlerTemplate.txt) next to partials. Every other .txt and .tmpl file in the
+// directory is a partial that templates can include with {{ template "<file name>" . }}.
+// The built-in template is used when the directory does not override the kind's template.
+func LoadTemplateOverride(templatePath, kind string) (string, map[string]string, error) {
+	g, err := getGeneratorKind(kind)
+	if err != nil {
+		return "", nil, err
+	}
+	info, err := os.Stat(templatePath)
+	if err != nil {
+		return "", nil, err
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(templatePath)
+		if err != nil {
+			return "", nil, err
+		}
+		return string(data), nil, nil
+	}
+
+	entries, err := os.ReadDir(templatePath)
+	if err != nil {
+		return "", nil, err
+	}
+	source := g.Template
+	partials := map[string]string{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".txt" && ext != ".tmpl") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(templatePath, entry.Name()))
+		if err != nil {
+			return "", nil, err
+		}
+		switch {
+		case entry.Name() == g.TemplateFile:
+			source = string(data)
+		case !isKindTemplateFile(entry.Name()):
+			partials[entry.Name()] = string(data)
+		}
+	}
+	return source, partials, nil
+}
+
+func isKindTemplateFile(name string) bool {
+	for _, g := range generatorKinds {
+		if g.TemplateFile == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTemplate parses a template and its partials with the function library bound to im.
+func parseTemplate(name, source string, partials map[string]string, im *importer) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs(im)).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	for partialName, partial := range partials {
+		if _, err := tmpl.New(partialName).Parse(partial); err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", partialName, err)
+		}
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEjectTemplates(t *testing.T) {
+	dir := t.TempDir()
+	written, err := EjectTemplates("handler", dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 1 || filepath.Base(written[0]) != "handlerTemplate.txt" {
+		t.Fatalf("got %v, want handlerTemplate.txt", written)
+	}
+	if _, err := EjectTemplates("handler", dir, false); err == nil {
+		t.Error("expected an error when the template already exists")
+	}
+	if _, err := EjectTemplates("nope", dir, false); err == nil {
+		t.Error("expected an error for an unknown template")
+	}
+
+	// The ejected directory is a valid template override
+	if err := os.WriteFile(filepath.Join(dir, "extra.tmpl"), []byte(`{{ define "unused" }}{{ end }}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	source, partials, err := LoadTemplateOverride(dir, "handler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != handlerTemplate {
+		t.Error("expected the ejected handler template")
+	}
+	if _, ok := partials["extra.tmpl"]; !ok {
+		t.Errorf("got partials %v, want extra.tmpl", partials)
+	}
+	if _, err := ValidateTemplate("handler", source, partials); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// typeCollector records the named types reachable from a set of types.
+type typeCollector struct {
+	defs map[string]TypeDef
+}
+
+func newTypeCollector() *typeCollector {
+	return &typeCollector{defs: map[string]TypeDef{}}
+}
+
+func qualifyByPath(pkg *types.Package) string {
+	return pkg.Path()
+}
+
+// add records t and every named type it refers to.
+func (c *typeCollector) add(t types.Type) {
+	switch t := t.(type) {
+	case *types.Pointer:
+		c.add(t.Elem())
+	case *types.Slice:
+		c.add(t.Elem())
+	case *types.Array:
+		c.add(t.Elem())
+	case *types.Map:
+		c.add(t.Key())
+		c.add(t.Elem())
+	case *types.Chan:
+		c.add(t.Elem())
+	case *types.Named:
+		obj := t.Obj()
+		if obj.Pkg() == nil {
+			// Predeclared types such as error
+			return
+		}
+		name := types.TypeString(t, qualifyByPath)
+		if _, ok := c.defs[name]; ok {
+			return
+		}
+		def := TypeDef{Name: name, Package: obj.Pkg().Path(), Kind: typeKind(t.Underlying())}
+		// Record the type before its fields, so recursive types terminate
+		c.defs[name] = def
+		switch u := t.Underlying().(type) {
+		case *types.Struct:
+			def.Fields = c.fields(u)
+		case *types.Interface:
+			// Interfaces are described by their name only
+		default:
+			def.Underlying = types.TypeString(t.Underlying(), qualifyByPath)
+			c.add(t.Underlying())
+		}
+		c.defs[name] = def
+	case *types.Struct:
+		c.fields(t)
+	}
+}
+
+// fields returns the exported fields of s as encoding/json sees them, collecting their types.
+func (c *typeCollector) fields(s *types.Struct) []Field {
+	var fields []Field
+	for i := 0; i < s.NumFields(); i++ {
+		v := s.Field(i)
+		jsonName, opts, _ := strings.Cut(reflect.StructTag(s.Tag(i)).Get("json"), ",")
+		if jsonName == "-" && opts == "" {
+			continue
+		}
+		if v.Embedded() && jsonName == "" {
+			embedded := v.Type()
+			if p, ok := embedded.(*types.Pointer); ok {
+				embedded = p.Elem()
+			}
+			if es, ok := embedded.Underlying().(*types.Struct); ok {
+				c.add(embedded)
+				fields = append(fields, c.fields(es)...)
+				continue
+			}
+		}
+		if !v.Exported() {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = v.Name()
+		}
+		c.add(v.Type())
+		fields = append(fields, Field{
+			Name:      v.Name(),
+			Type:      types.TypeString(v.Type(), qualifyByPath),
+			JSONName:  jsonName,
+			OmitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+		})
+	}
+	return fields
+}
+
+// list returns the collected types sorted by name.
+func (c *typeCollector) list() []TypeDef {
+	defs := make([]TypeDef, 0, len(c.defs))
+	for _, def := range c.defs {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+func typeKind(t types.Type) string {
+	switch t.(type) {
+	case *types.Basic:
+		return "basic"
+	case *types.Struct:
+		return "struct"
+	case *types.Interface:
+		return "interface"
+	case *types.Pointer:
+		return "pointer"
+	case *types.Slice:
+		return "slice"
+	case *types.Array:
+		return "array"
+	case *types.Map:
+		return "map"
+	case *types.Signature:
+		return "func"
+	case *types.Chan:
+		return "chan"
+	default:
+		return "other"
+	}
+}
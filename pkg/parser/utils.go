@@ -250,12 +250,19 @@ func parseType(expr ast.Expr) DataType {
 	return DataType{}
 }
 
-func getUniqueVarName(baseName string) string {
+// getUniqueVarName returns baseName, suffixed with a number when it collides with r, w, ctx
+// or the name of one of params
+func getUniqueVarName(baseName string, params ...[]Param) string {
 	existingNames := map[string]bool{
 		"r":   true,
 		"w":   true,
 		"ctx": true,
 	}
+	for _, ps := range params {
+		for _, p := range ps {
+			existingNames[p.Name] = true
+		}
+	}
 	name := baseName
 	i := 1
 	for existingNames[name] {
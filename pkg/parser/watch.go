@@ -0,0 +1,342 @@
+package parser
+
+import (
+	"context"
+	"go/token"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Seann-Moser/go-serve/pkg/ctxLogger"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultDebounce is how long the Watcher waits for a burst of saves to settle.
+const DefaultDebounce = 300 * time.Millisecond
+
+// Watcher regenerates the outputs of its targets whenever the Go files they were rendered from change.
+type Watcher struct {
+	Targets  []Target
+	Debounce time.Duration
+
+	// rendered holds the last output of every target per source package directory
+	rendered map[watchKey][]GeneratedFile
+	// imports holds the directories of the local packages imported by every rendered source package,
+	// so that changing a dependency regenerates the packages using its types
+	imports map[string]map[string]bool
+	// loaded holds the package of every rendered source file, kept until its package or a
+	// package it imports changes. All packages are positioned in fset.
+	loaded map[string]*packages.Package
+	fset   *token.FileSet
+}
+
+type watchKey struct {
+	target string
+	dir    string
+}
+
+func NewWatcher(targets []Target, debounce time.Duration) *Watcher {
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	return &Watcher{
+		Targets:  targets,
+		Debounce: debounce,
+		rendered: map[watchKey][]GeneratedFile{},
+		imports:  map[string]map[string]bool{},
+		loaded:   map[string]*packages.Package{},
+		fset:     token.NewFileSet(),
+	}
+}
+
+// Run renders every target once and then regenerates the packages that change
+// until ctx is cancelled. Generation errors are logged and do not stop the watcher.
+func (w *Watcher) Run(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsw.Close()
+
+	for _, t := range w.Targets {
+		for _, source := range t.Sources {
+			if err := w.watchDirs(fsw, source, true); err != nil {
+				return err
+			}
+		}
+	}
+	w.regenerate(ctx, nil)
+	w.watchImports(ctx, fsw)
+	ctxLogger.Info(ctx, "Watching for changes", zap.Int("directories", len(fsw.WatchList())))
+
+	changed := map[string]bool{}
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.watchDirs(fsw, event.Name, false); err != nil {
+						ctxLogger.Error(ctx, "Failed to watch directory", zap.String("dir", event.Name), zap.Error(err))
+					}
+					continue
+				}
+			}
+			if !strings.HasSuffix(event.Name, ".go") {
+				continue
+			}
+			dir, err := filepath.Abs(filepath.Dir(event.Name))
+			if err != nil {
+				continue
+			}
+			changed[dir] = true
+			debounce = time.After(w.Debounce)
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			ctxLogger.Error(ctx, "Watch error", zap.Error(err))
+		case <-debounce:
+			w.regenerate(ctx, changed)
+			w.watchImports(ctx, fsw)
+			changed = map[string]bool{}
+			debounce = nil
+		}
+	}
+}
+
+// watchDirs adds root and its sub directories, skipping the same directories as FindInterfaces.
+// A source root is always watched, even when its name would be skipped.
+func (w *Watcher) watchDirs(fsw *fsnotify.Watcher, root string, source bool) error {
+	var outputs []string
+	for _, t := range w.Targets {
+		outputs = append(outputs, t.Output)
+	}
+	ignored, err := absDirs(outputs)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(root, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		dir, err := filepath.Abs(filePath)
+		if err != nil {
+			return err
+		}
+		if (filePath != root || !source) && skipDir(dir, ignored) {
+			return filepath.SkipDir
+		}
+		return fsw.Add(filePath)
+	})
+}
+
+// watchImports also watches the imported local packages that are outside of the sources.
+func (w *Watcher) watchImports(ctx context.Context, fsw *fsnotify.Watcher) {
+	watched := map[string]bool{}
+	for _, dir := range fsw.WatchList() {
+		if abs, err := filepath.Abs(dir); err == nil {
+			watched[abs] = true
+		}
+	}
+	for _, dirs := range w.imports {
+		for dir := range dirs {
+			if watched[dir] {
+				continue
+			}
+			watched[dir] = true
+			if err := fsw.Add(dir); err != nil {
+				ctxLogger.Error(ctx, "Failed to watch directory", zap.String("dir", dir), zap.Error(err))
+			}
+		}
+	}
+}
+
+// withImporters adds the directories of the rendered packages importing a changed package to changed.
+func (w *Watcher) withImporters(changed map[string]bool) map[string]bool {
+	if changed == nil {
+		return nil
+	}
+	expanded := maps.Clone(changed)
+	for dir, imports := range w.imports {
+		for imported := range imports {
+			if changed[imported] {
+				expanded[dir] = true
+				break
+			}
+		}
+	}
+	return expanded
+}
+
+// regenerate renders the packages in the changed directories and the packages importing them,
+// or every package when changed is nil, and writes the outputs that differ from the files on disk.
+// Only those packages are loaded again, in one pass, the others are rendered from memory.
+func (w *Watcher) regenerate(ctx context.Context, changed map[string]bool) {
+	changed = w.withImporters(changed)
+	var jobs []RenderJob
+	var keys []watchKey
+	var targets []Target
+	for _, t := range w.Targets {
+		// Plugins render all interfaces of a target at once, so they always get every package
+		dirs := changed
+		kind, err := getGeneratorKind(t.Kind)
+		isPlugin := err == nil && kind.Plugin != ""
+		if isPlugin {
+			dirs = nil
+		}
+		targetJobs, err := targetRenderJobs(t, dirs)
+		if err != nil {
+			ctxLogger.Error(ctx, "Failed to find interfaces", zap.String("target", t.Name), zap.Error(err))
+			continue
+		}
+		rendered := map[watchKey]bool{}
+		for _, job := range targetJobs {
+			key := watchKey{target: t.Name, dir: job.File.Dir}
+			if isPlugin {
+				key.dir = ""
+			}
+			jobs = append(jobs, job)
+			keys = append(keys, key)
+			targets = append(targets, t)
+			rendered[key] = true
+		}
+		// Packages that no longer contain any selected interface
+		for dir := range changed {
+			key := watchKey{target: t.Name, dir: dir}
+			if _, seen := w.rendered[key]; seen && !rendered[key] {
+				w.write(ctx, t, w.rendered[key], nil)
+				delete(w.rendered, key)
+			}
+		}
+	}
+	if len(jobs) == 0 {
+		return
+	}
+
+	gofiles := make([]FileInterface, len(jobs))
+	for j := range jobs {
+		gofiles[j] = jobs[j].File
+	}
+	loaded, err := w.load(ctx, gofiles, changed)
+	if err != nil {
+		ctxLogger.Error(ctx, "Failed to regenerate", zap.Error(err))
+		return
+	}
+	perJob, err := renderLoadedJobs(ctx, loaded, jobs, nil)
+	if err != nil {
+		ctxLogger.Error(ctx, "Failed to regenerate", zap.Error(err))
+		return
+	}
+	byKey := map[watchKey][]GeneratedFile{}
+	var order []watchKey
+	for j, files := range perJob {
+		if _, ok := byKey[keys[j]]; !ok {
+			order = append(order, keys[j])
+		}
+		byKey[keys[j]] = append(byKey[keys[j]], files...)
+	}
+	for _, key := range order {
+		w.write(ctx, targets[slices.Index(keys, key)], w.rendered[key], byKey[key])
+		w.rendered[key] = byKey[key]
+	}
+}
+
+// load returns the packages of gofiles, loading the ones in the changed directories, or all of them
+// when changed is nil, and taking the others from the packages loaded before.
+func (w *Watcher) load(ctx context.Context, gofiles []FileInterface, changed map[string]bool) (*Packages, error) {
+	files := map[string]bool{}
+	var stale []FileInterface
+	for _, gofile := range gofiles {
+		key := loadedKey(gofile)
+		files[key] = true
+		if changed == nil || changed[gofile.Dir] || gofile.Dir == "" || w.loaded[key] == nil {
+			stale = append(stale, gofile)
+		}
+	}
+	// Forget the files of the changed packages that no longer contain any selected interface
+	for key := range w.loaded {
+		if !files[key] && (changed == nil || changed[filepath.Dir(key)]) {
+			delete(w.loaded, key)
+		}
+	}
+	if len(stale) > 0 {
+		fresh, err := loadPackages(ctx, stale, nil, loadOptions{fset: w.fset})
+		if err != nil {
+			return nil, err
+		}
+		for _, gofile := range stale {
+			pkg, err := fresh.Lookup(gofile)
+			if err != nil {
+				return nil, err
+			}
+			w.loaded[loadedKey(gofile)] = pkg
+			if gofile.Dir != "" {
+				w.imports[gofile.Dir] = localImports(pkg)
+			}
+		}
+	}
+
+	loaded := newPackages(w.fset)
+	for _, gofile := range gofiles {
+		loaded.add(w.loaded[loadedKey(gofile)])
+	}
+	return loaded, nil
+}
+
+// loadedKey identifies the package of gofile in Watcher.loaded.
+func loadedKey(gofile FileInterface) string {
+	return filepath.Join(gofile.Dir, filepath.Base(gofile.FilePath))
+}
+
+func (w *Watcher) write(ctx context.Context, t Target, previous, files []GeneratedFile) {
+	stale, err := DiffGeneratedFiles(files)
+	if err != nil {
+		ctxLogger.Error(ctx, "Failed to compare generated files", zap.Error(err))
+		return
+	}
+	var toWrite []GeneratedFile
+	for _, d := range stale {
+		for _, f := range files {
+			if f.Path == d.Path {
+				toWrite = append(toWrite, f)
+			}
+		}
+	}
+	if err := WriteGeneratedFiles(toWrite); err != nil {
+		ctxLogger.Error(ctx, "Failed to write generated files", zap.Error(err))
+		return
+	}
+	for _, f := range toWrite {
+		ctxLogger.Info(ctx, "Regenerated "+f.Path, zap.String("target", t.Name))
+	}
+
+	for _, p := range previous {
+		if !containsGeneratedFile(files, p) {
+			ctxLogger.Warn(ctx, "Output is no longer generated, remove it if it is unused", zap.String("path", p.Path))
+		}
+	}
+}
+
+func containsGeneratedFile(files []GeneratedFile, file GeneratedFile) bool {
+	for _, f := range files {
+		if f.Path == file.Path {
+			return true
+		}
+	}
+	return false
+}
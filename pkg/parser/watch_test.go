@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForFile polls filePath until it contains substr
+func waitForFile(t *testing.T, filePath, substr string) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, err := os.ReadFile(filePath); err == nil && strings.Contains(string(data), substr) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	data, _ := os.ReadFile(filePath)
+	t.Fatalf("%s does not contain %q:\n%s", filePath, substr, data)
+}
+
+func TestWatcherRegeneratesImporters(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "models", "models.go"), "package models\n\ntype User struct {\n\tName string\n}\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\nimport \"example.com/root/models\"\n\ntype UserService interface {\n\tGetUser(id string) (*models.User, error)\n}\n")
+	writeTestFile(t, filepath.Join(root, "orders", "orders.go"), "package orders\n\ntype OrderService interface {\n\tGetOrder(id string) (string, error)\n}\n")
+
+	out := filepath.Join(root, "out")
+	// models is outside of the sources, it is only watched because users imports it
+	w := NewWatcher([]Target{{
+		Name:    "proto",
+		Sources: []string{filepath.Join(root, "users"), filepath.Join(root, "orders")},
+		Kind:    "proto",
+		Output:  out,
+	}}, 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}()
+
+	usersProto := filepath.Join(out, "user_service.proto")
+	ordersProto := filepath.Join(out, "order_service.proto")
+	waitForFile(t, usersProto, "string name = 1;")
+	waitForFile(t, ordersProto, "GetOrder")
+	if err := os.Remove(ordersProto); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, filepath.Join(root, "models", "models.go"), "package models\n\ntype User struct {\n\tName  string\n\tEmail string\n}\n")
+	waitForFile(t, usersProto, "string email = 2;")
+
+	// Only the importers of the changed package are regenerated
+	if _, err := os.Stat(ordersProto); !os.IsNotExist(err) {
+		t.Errorf("regenerated %s, which does not import the changed package: %v", ordersProto, err)
+	}
+
+	// Changing a source package regenerates it
+	writeTestFile(t, filepath.Join(root, "orders", "orders.go"), "package orders\n\ntype OrderService interface {\n\tGetOrder(id string) (string, error)\n\tCancelOrder(id string) error\n}\n")
+	waitForFile(t, ordersProto, "CancelOrder")
+}
+
+func TestWatcherKeepsLoadedPackages(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "go.mod"), "module example.com/root\n\ngo 1.23\n")
+	writeTestFile(t, filepath.Join(root, "models", "models.go"), "package models\n\ntype User struct {\n\tName string\n}\n")
+	writeTestFile(t, filepath.Join(root, "users", "users.go"), "package users\n\nimport \"example.com/root/models\"\n\ntype UserService interface {\n\tGetUser(id string) (*models.User, error)\n}\n")
+	writeTestFile(t, filepath.Join(root, "orders", "orders.go"), "package orders\n\ntype OrderService interface {\n\tGetOrder(id string) (string, error)\n}\n")
+
+	out := filepath.Join(root, "out")
+	w := NewWatcher([]Target{{
+		Name:    "proto",
+		Sources: []string{filepath.Join(root, "users"), filepath.Join(root, "orders")},
+		Kind:    "proto",
+		Output:  out,
+	}}, time.Millisecond)
+	ctx := context.Background()
+	w.regenerate(ctx, nil)
+	users, orders := filepath.Join(root, "users", "users.go"), filepath.Join(root, "orders", "orders.go")
+	usersPkg, ordersPkg := w.loaded[users], w.loaded[orders]
+	if usersPkg == nil || ordersPkg == nil {
+		t.Fatalf("got %v, want users and orders loaded", w.loaded)
+	}
+
+	writeTestFile(t, filepath.Join(root, "models", "models.go"), "package models\n\ntype User struct {\n\tName  string\n\tEmail string\n}\n")
+	w.regenerate(ctx, map[string]bool{filepath.Join(root, "models"): true})
+	if w.loaded[users] == usersPkg {
+		t.Error("got the users package loaded before models changed, want it reloaded")
+	}
+	waitForFile(t, filepath.Join(out, "user_service.proto"), "string email = 2;")
+
+	// Plugins get every package, the unchanged ones come from memory
+	jobs, err := targetRenderJobs(w.Targets[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gofiles := make([]FileInterface, len(jobs))
+	for j := range jobs {
+		gofiles[j] = jobs[j].File
+	}
+	loaded, err := w.load(ctx, gofiles, map[string]bool{filepath.Join(root, "users"): true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg, err := loaded.Lookup(gofiles[slices.IndexFunc(gofiles, func(f FileInterface) bool { return f.Dir == filepath.Join(root, "orders") })]); err != nil || pkg != ordersPkg {
+		t.Errorf("got %v, %v, want the orders package in memory", pkg, err)
+	}
+}